@@ -0,0 +1,141 @@
+package main
+
+import (
+	"auth-system/internal/infrastructure/database"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+func migrateUp(c *cli, args []string) error {
+	flags := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	migrator, err := c.migrator()
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		return err
+	}
+	return c.printMigrations(applied, "applied")
+}
+
+func migrateDown(c *cli, args []string) error {
+	flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	steps := flags.Int("steps", 1, "")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return fmt.Errorf("%w: -steps must be at least 1", errUsage)
+	}
+
+	migrator, err := c.migrator()
+	if err != nil {
+		return err
+	}
+
+	rolledBack, err := migrator.Down(*steps)
+	if err != nil {
+		return err
+	}
+	return c.printMigrations(rolledBack, "rolled back")
+}
+
+func migrateTo(c *cli, args []string) error {
+	flags := flag.NewFlagSet("migrate to", flag.ContinueOnError)
+	positional, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	version, err := strconv.Atoi(positional[0])
+	if err != nil || version < 0 {
+		return fmt.Errorf("%w: invalid version %s", errUsage, positional[0])
+	}
+
+	migrator, err := c.migrator()
+	if err != nil {
+		return err
+	}
+
+	migrated, err := migrator.To(version)
+	if err != nil {
+		return err
+	}
+	return c.printMigrations(migrated, "migrated")
+}
+
+func migrateStatus(c *cli, args []string) error {
+	flags := flag.NewFlagSet("migrate status", flag.ContinueOnError)
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	migrator, err := c.migrator()
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(statuses))
+	for i, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		rows[i] = []string{strconv.Itoa(status.Version), status.Name, appliedAt}
+	}
+	return c.print(statuses, []string{"VERSION", "NAME", "APPLIED"}, rows)
+}
+
+func seed(c *cli, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	db, err := c.database()
+	if err != nil {
+		return err
+	}
+
+	if err := database.Seed(db, &c.config().Bootstrap); err != nil {
+		return err
+	}
+	return c.done("Default roles and permissions seeded")
+}
+
+func (c *cli) migrator() (*database.Migrator, error) {
+	db, err := c.database()
+	if err != nil {
+		return nil, err
+	}
+	return database.NewMigrator(db)
+}
+
+// printMigrations lists the migrations a command ran, in the order it ran
+// them.
+func (c *cli) printMigrations(migrations []database.Migration, verb string) error {
+	if len(migrations) == 0 && !c.json {
+		return c.done("Nothing to do")
+	}
+
+	rows := make([][]string, len(migrations))
+	for i, migration := range migrations {
+		rows[i] = []string{strconv.Itoa(migration.Version), migration.Name, verb}
+	}
+	if migrations == nil {
+		migrations = []database.Migration{}
+	}
+	return c.print(migrations, []string{"VERSION", "NAME", "RESULT"}, rows)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"auth-system/internal/application/dto"
+	"flag"
+	"time"
+)
+
+func listKeys(c *cli, args []string) error {
+	flags := flag.NewFlagSet("keys list", flag.ContinueOnError)
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	keys, err := application.SigningKeyService.ListSigningKeys()
+	if err != nil {
+		return err
+	}
+	return c.printKeys(keys, keys)
+}
+
+func rotateKey(c *cli, args []string) error {
+	flags := flag.NewFlagSet("keys rotate", flag.ContinueOnError)
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	key, err := application.SigningKeyService.RotateSigningKey()
+	if err != nil {
+		return err
+	}
+	return c.printKeys(key, []dto.SigningKeyResponse{*key})
+}
+
+func (c *cli) printKeys(v interface{}, keys []dto.SigningKeyResponse) error {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	rows := make([][]string, len(keys))
+	for i, key := range keys {
+		rows[i] = []string{
+			key.KID,
+			key.Algorithm,
+			key.Status,
+			formatTime(&key.ActivatedAt),
+			formatTime(key.RetiredAt),
+			formatTime(key.VerifyUntil),
+		}
+	}
+	return c.print(v, []string{"KID", "ALGORITHM", "STATUS", "ACTIVATED", "RETIRED", "VERIFY UNTIL"}, rows)
+}
@@ -0,0 +1,248 @@
+// Command authctl administers an auth-system installation from the shell.
+// It talks to the database directly through the same repositories and
+// services as the server, so it needs the server's environment.
+package main
+
+import (
+	"auth-system/internal/app"
+	"auth-system/internal/config"
+	"auth-system/internal/infrastructure/database"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const usage = `Usage: authctl [-o table|json] <command> [arguments]
+
+Commands:
+  user create      -email E -first-name F -last-name L (-password P | -invite)
+                   [-locale L] [-verified] [-role R]...
+  user list        [-search S] [-role R] [-sort S] [-deleted] [-page N] [-per-page N]
+  user disable     [-reason R] <user>
+  user enable      [-reason R] <user>
+  user set-password [-password P] <user>   (reads the password from stdin if omitted)
+  user revoke-sessions <user>
+  user check       <user> <resource> <action>   (explains the decision)
+  role list
+  role grant       <user> <role>
+  role revoke      <user> <role>
+  rbac export                         (YAML unless -o json)
+  rbac plan        <manifest>
+  rbac apply       <manifest>
+  migrate status
+  migrate up
+  migrate down     [-steps N]
+  migrate to       <version>          (0 rolls back everything)
+  seed
+  keys list
+  keys rotate
+
+A <user> is an ID or an email address, a <role> a role name.
+`
+
+// errUsage reports a malformed command line; the usage is printed with it.
+var errUsage = errors.New("invalid arguments")
+
+type command struct {
+	name string
+	run  func(c *cli, args []string) error
+}
+
+var commands = map[string][]command{
+	"user": {
+		{"create", createUser},
+		{"list", listUsers},
+		{"disable", disableUser},
+		{"enable", enableUser},
+		{"set-password", setPassword},
+		{"revoke-sessions", revokeSessions},
+		{"check", checkPermission},
+	},
+	"role": {
+		{"list", listRoles},
+		{"grant", grantRole},
+		{"revoke", revokeRole},
+	},
+	"rbac": {
+		{"export", exportRBAC},
+		{"plan", planRBAC},
+		{"apply", applyRBAC},
+	},
+	"migrate": {
+		{"up", migrateUp},
+		{"down", migrateDown},
+		{"to", migrateTo},
+		{"status", migrateStatus},
+	},
+	"keys": {
+		{"list", listKeys},
+		{"rotate", rotateKey},
+	},
+}
+
+func main() {
+	flags := flag.NewFlagSet("authctl", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	output := flags.String("o", "table", "Output format: table or json")
+	flags.Parse(os.Args[1:])
+
+	if *output != "table" && *output != "json" {
+		fail(fmt.Errorf("unknown output format: %s", *output))
+	}
+
+	c := &cli{out: os.Stdout, json: *output == "json"}
+	err := c.dispatch(flags.Args())
+	c.close()
+
+	if errors.Is(err, errUsage) {
+		if err != errUsage {
+			fmt.Fprintf(os.Stderr, "authctl: %v\n", err)
+		}
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "authctl: %v\n", err)
+	os.Exit(1)
+}
+
+func (c *cli) dispatch(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	if args[0] == "seed" {
+		return seed(c, args[1:])
+	}
+	if len(args) < 2 {
+		return errUsage
+	}
+	for _, cmd := range commands[args[0]] {
+		if cmd.name == args[1] {
+			return cmd.run(c, args[2:])
+		}
+	}
+	return errUsage
+}
+
+// cli holds the state shared by the commands. The database and the
+// services are set up on first use, so commands like "migrate" work
+// without a complete configuration.
+type cli struct {
+	out  io.Writer
+	json bool
+
+	cfg *config.Config
+	db  *gorm.DB
+	app *app.App
+}
+
+func (c *cli) config() *config.Config {
+	if c.cfg == nil {
+		c.cfg = config.Load()
+	}
+	return c.cfg
+}
+
+func (c *cli) database() (*gorm.DB, error) {
+	if c.db != nil {
+		return c.db, nil
+	}
+	db, err := database.NewConnection(&c.config().Database)
+	if err != nil {
+		return nil, err
+	}
+	// The SQL log would mix with the command output on stdout.
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	c.db = db
+	return db, nil
+}
+
+func (c *cli) application() (*app.App, error) {
+	if c.app != nil {
+		return c.app, nil
+	}
+	db, err := c.database()
+	if err != nil {
+		return nil, err
+	}
+	application, err := app.New(c.config(), db)
+	if err != nil {
+		return nil, err
+	}
+	c.app = application
+	return application, nil
+}
+
+func (c *cli) close() {
+	if c.app != nil {
+		c.app.Close()
+	}
+	if c.db != nil {
+		if sqlDB, err := c.db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+}
+
+// print writes v as JSON, or header and rows as a table.
+func (c *cli) print(v interface{}, header []string, rows [][]string) error {
+	if c.json {
+		encoder := json.NewEncoder(c.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// done reports the outcome of a command without a result.
+func (c *cli) done(message string) error {
+	if c.json {
+		return c.print(map[string]string{"message": message}, nil, nil)
+	}
+	_, err := fmt.Fprintln(c.out, message)
+	return err
+}
+
+// parseFlags parses the flags of a subcommand and checks the number of
+// positional arguments.
+func parseFlags(flags *flag.FlagSet, args []string, positional int) ([]string, error) {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if flags.NArg() != positional {
+		return nil, errUsage
+	}
+	return flags.Args(), nil
+}
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
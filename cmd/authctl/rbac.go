@@ -0,0 +1,114 @@
+package main
+
+import (
+	"auth-system/internal/app"
+	"auth-system/internal/application/dto"
+	"flag"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exportRBAC writes the catalog as a manifest, in YAML unless JSON output
+// is selected.
+func exportRBAC(c *cli, args []string) error {
+	flags := flag.NewFlagSet("rbac export", flag.ContinueOnError)
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := application.RBACService.ExportManifest()
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.print(manifest, nil, nil)
+	}
+	encoder := yaml.NewEncoder(c.out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func planRBAC(c *cli, args []string) error {
+	return reconcileRBAC(c, "rbac plan", args, false)
+}
+
+func applyRBAC(c *cli, args []string) error {
+	return reconcileRBAC(c, "rbac apply", args, true)
+}
+
+func reconcileRBAC(c *cli, name string, args []string, apply bool) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	positional, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := app.LoadRBACManifest(positional[0])
+	if err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	var plan *dto.RBACPlan
+	if apply {
+		plan, err = application.RBACService.ApplyManifest(manifest)
+	} else {
+		plan, err = application.RBACService.PlanManifest(manifest)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !plan.HasChanges && !c.json {
+		return c.done("The catalog matches the manifest")
+	}
+	return c.printPlan(plan)
+}
+
+func (c *cli) printPlan(plan *dto.RBACPlan) error {
+	var rows [][]string
+	for _, name := range plan.CreatePermissions {
+		rows = append(rows, []string{"+", "permission", name})
+	}
+	for _, name := range plan.UpdatePermissions {
+		rows = append(rows, []string{"~", "permission", name})
+	}
+	for _, name := range plan.CreateRoles {
+		rows = append(rows, []string{"+", "role", name})
+	}
+	for _, name := range plan.UpdateRoles {
+		rows = append(rows, []string{"~", "role", name})
+	}
+	for _, grant := range plan.Grant {
+		rows = append(rows, []string{"+", "grant", grant.Role + " " + grant.Permission})
+	}
+	for _, grant := range plan.Revoke {
+		rows = append(rows, []string{"-", "grant", grant.Role + " " + grant.Permission})
+	}
+	for _, grant := range plan.Deny {
+		rows = append(rows, []string{"+", "deny", grant.Role + " " + grant.Permission})
+	}
+	for _, grant := range plan.Undeny {
+		rows = append(rows, []string{"-", "deny", grant.Role + " " + grant.Permission})
+	}
+	for _, parent := range plan.AddParents {
+		rows = append(rows, []string{"+", "parent", parent.Role + " " + parent.Parent})
+	}
+	for _, parent := range plan.RemoveParents {
+		rows = append(rows, []string{"-", "parent", parent.Role + " " + parent.Parent})
+	}
+	return c.print(plan, []string{"CHANGE", "KIND", "NAME"}, rows)
+}
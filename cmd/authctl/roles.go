@@ -0,0 +1,105 @@
+package main
+
+import (
+	"auth-system/internal/app"
+	"auth-system/internal/domain/entities"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+func listRoles(c *cli, args []string) error {
+	flags := flag.NewFlagSet("role list", flag.ContinueOnError)
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	roles, err := application.RoleService.ListRoles()
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(roles))
+	for i, role := range roles {
+		parents := make([]string, len(role.Parents))
+		for j, parent := range role.Parents {
+			parents[j] = parent.Name
+		}
+		permissions := make([]string, len(role.Permissions))
+		for j, permission := range role.Permissions {
+			permissions[j] = permission.Name
+		}
+		denied := make([]string, len(role.DeniedPermissions))
+		for j, permission := range role.DeniedPermissions {
+			denied[j] = permission.Name
+		}
+		rows[i] = []string{
+			strconv.FormatUint(uint64(role.ID), 10),
+			role.Name,
+			role.Description,
+			strings.Join(parents, ","),
+			strings.Join(permissions, ","),
+			strings.Join(denied, ","),
+		}
+	}
+	return c.print(roles, []string{"ID", "NAME", "DESCRIPTION", "INHERITS", "PERMISSIONS", "DENIES"}, rows)
+}
+
+func grantRole(c *cli, args []string) error {
+	return changeRole(c, "role grant", args, true)
+}
+
+func revokeRole(c *cli, args []string) error {
+	return changeRole(c, "role revoke", args, false)
+}
+
+func changeRole(c *cli, name string, args []string, grant bool) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	positional, err := parseFlags(flags, args, 2)
+	if err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(application, positional[0])
+	if err != nil {
+		return err
+	}
+
+	role, err := findRole(application, positional[1])
+	if err != nil {
+		return err
+	}
+
+	if grant {
+		if err := application.UserService.AssignRole(user.ID, role.ID); err != nil {
+			return err
+		}
+		return c.done(fmt.Sprintf("Role %s granted to %s", role.Name, user.Email))
+	}
+
+	if err := application.UserService.RemoveRole(user.ID, role.ID); err != nil {
+		return err
+	}
+	return c.done(fmt.Sprintf("Role %s revoked from %s", role.Name, user.Email))
+}
+
+func findRole(application *app.App, name string) (*entities.Role, error) {
+	role, err := application.RoleRepo.GetByName(name)
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("role %s not found", name)
+	}
+	return role, err
+}
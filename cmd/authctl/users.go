@@ -0,0 +1,260 @@
+package main
+
+import (
+	"auth-system/internal/app"
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// operatorID is passed as the acting administrator; the security events
+// then name an operator instead of a user.
+const operatorID = 0
+
+func createUser(c *cli, args []string) error {
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	var roles stringList
+	req := dto.CreateUserRequest{}
+	flags.StringVar(&req.Email, "email", "", "")
+	flags.StringVar(&req.Password, "password", "", "")
+	flags.StringVar(&req.FirstName, "first-name", "", "")
+	flags.StringVar(&req.LastName, "last-name", "", "")
+	flags.StringVar(&req.Locale, "locale", "", "")
+	flags.BoolVar(&req.EmailVerified, "verified", false, "")
+	flags.BoolVar(&req.SendInvite, "invite", false, "")
+	flags.Var(&roles, "role", "")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	// Apply the same rules as the API
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range roles {
+		role, err := findRole(application, name)
+		if err != nil {
+			return err
+		}
+		req.RoleIDs = append(req.RoleIDs, role.ID)
+	}
+
+	user, err := application.UserService.CreateUser(operatorID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.printUsers(user, []dto.UserResponse{*user})
+}
+
+func listUsers(c *cli, args []string) error {
+	flags := flag.NewFlagSet("user list", flag.ContinueOnError)
+	req := dto.ListUsersRequest{}
+	flags.StringVar(&req.Search, "search", "", "")
+	flags.StringVar(&req.Role, "role", "", "")
+	flags.StringVar(&req.Sort, "sort", "", "")
+	flags.BoolVar(&req.Deleted, "deleted", false, "")
+	flags.IntVar(&req.Page, "page", 1, "")
+	flags.IntVar(&req.PerPage, "per-page", 50, "")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	page, err := application.UserService.ListUsers(&req)
+	if err != nil {
+		return err
+	}
+
+	users, _ := page.Data.([]dto.UserResponse)
+	if err := c.printUsers(page, users); err != nil {
+		return err
+	}
+	if !c.json && page.TotalPages > 1 {
+		fmt.Fprintf(c.out, "\nPage %d of %d, %d users\n", page.Page, page.TotalPages, page.Total)
+	}
+	return nil
+}
+
+func disableUser(c *cli, args []string) error {
+	return setUserStatus(c, "user disable", args, false)
+}
+
+func enableUser(c *cli, args []string) error {
+	return setUserStatus(c, "user enable", args, true)
+}
+
+func setUserStatus(c *cli, name string, args []string, active bool) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	req := dto.UserStatusRequest{}
+	flags.StringVar(&req.Reason, "reason", "Changed with authctl", "")
+	positional, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(application, positional[0])
+	if err != nil {
+		return err
+	}
+
+	if active {
+		if err := application.UserService.ReactivateUser(operatorID, user.ID, &req); err != nil {
+			return err
+		}
+		return c.done(fmt.Sprintf("User %s reactivated", user.Email))
+	}
+
+	if err := application.UserService.DeactivateUser(operatorID, user.ID, &req); err != nil {
+		return err
+	}
+	return c.done(fmt.Sprintf("User %s deactivated", user.Email))
+}
+
+func setPassword(c *cli, args []string) error {
+	flags := flag.NewFlagSet("user set-password", flag.ContinueOnError)
+	password := flags.String("password", "", "")
+	positional, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	// Reading from stdin keeps the password out of the shell history
+	if *password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(application, positional[0])
+	if err != nil {
+		return err
+	}
+
+	if err := application.UserService.SetPassword(operatorID, user.ID, *password); err != nil {
+		return err
+	}
+	return c.done(fmt.Sprintf("Password of %s changed, all sessions revoked", user.Email))
+}
+
+func revokeSessions(c *cli, args []string) error {
+	flags := flag.NewFlagSet("user revoke-sessions", flag.ContinueOnError)
+	positional, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(application, positional[0])
+	if err != nil {
+		return err
+	}
+
+	if err := application.SessionService.RevokeAllSessions(user.ID); err != nil {
+		return err
+	}
+	return c.done(fmt.Sprintf("All sessions of %s revoked", user.Email))
+}
+
+// checkPermission explains how a permission check for the user is decided.
+func checkPermission(c *cli, args []string) error {
+	flags := flag.NewFlagSet("user check", flag.ContinueOnError)
+	positional, err := parseFlags(flags, args, 3)
+	if err != nil {
+		return err
+	}
+
+	application, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(application, positional[0])
+	if err != nil {
+		return err
+	}
+
+	decision, err := application.PermissionService.Decide(user.ID, positional[1], positional[2])
+	if err != nil {
+		return err
+	}
+
+	result := "denied"
+	if decision.Allowed {
+		result = "allowed"
+	}
+	rows := [][]string{{decision.Resource + "." + decision.Action, result, decision.Reason}}
+	return c.print(decision, []string{"PERMISSION", "RESULT", "REASON"}, rows)
+}
+
+// findUser looks a user up by ID or, if arg is not a number, by email.
+func findUser(application *app.App, arg string) (*entities.User, error) {
+	var user *entities.User
+	var err error
+	if id, parseErr := strconv.ParseUint(arg, 10, 32); parseErr == nil {
+		user, err = application.UserRepo.GetByID(uint(id))
+	} else {
+		user, err = application.UserRepo.GetByEmail(arg)
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("user %s not found", arg)
+	}
+	return user, err
+}
+
+func (c *cli) printUsers(v interface{}, users []dto.UserResponse) error {
+	rows := make([][]string, len(users))
+	for i, user := range users {
+		roles := make([]string, len(user.Roles))
+		for j, role := range user.Roles {
+			roles[j] = role.Name
+		}
+		rows[i] = []string{
+			strconv.FormatUint(uint64(user.ID), 10),
+			user.Email,
+			strings.TrimSpace(user.FirstName + " " + user.LastName),
+			strconv.FormatBool(user.IsActive),
+			strconv.FormatBool(user.EmailVerified),
+			strings.Join(roles, ","),
+		}
+	}
+	return c.print(v, []string{"ID", "EMAIL", "NAME", "ACTIVE", "VERIFIED", "ROLES"}, rows)
+}
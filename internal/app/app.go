@@ -0,0 +1,280 @@
+// Package app wires the repositories and services shared by the HTTP server
+// and the authctl command.
+package app
+
+import (
+	"auth-system/internal/application/services"
+	"auth-system/internal/config"
+	domainrepos "auth-system/internal/domain/repositories"
+	domainservices "auth-system/internal/domain/services"
+	"auth-system/internal/infrastructure/mail"
+	"auth-system/internal/infrastructure/repositories"
+	"auth-system/internal/infrastructure/security"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type App struct {
+	Config *config.Config
+	DB     *gorm.DB
+
+	Keyring         *security.Keyring
+	JWTManager      *security.JWTManager
+	PasswordManager *security.PasswordManager
+	Mailer          mail.Mailer
+	LockoutPolicy   services.LockoutPolicy
+
+	UserRepo               domainrepos.UserRepository
+	RoleRepo               domainrepos.RoleRepository
+	PermissionRepo         domainrepos.PermissionRepository
+	RevokedTokenRepo       domainrepos.RevokedTokenRepository
+	RefreshTokenRepo       domainrepos.RefreshTokenRepository
+	SessionRepo            domainrepos.SessionRepository
+	SecurityEventRepo      domainrepos.SecurityEventRepository
+	WebAuthnChallengeRepo  domainrepos.WebAuthnChallengeRepository
+	PasswordResetTokenRepo domainrepos.PasswordResetTokenRepository
+	IPLoginFailureRepo     domainrepos.IPLoginFailureRepository
+
+	AuthService              domainservices.AuthService
+	UserService              domainservices.UserService
+	RoleService              domainservices.RoleService
+	PermissionService        domainservices.PermissionService
+	RBACService              domainservices.RBACService
+	SessionService           domainservices.SessionService
+	MFAService               domainservices.MFAService
+	WebAuthnService          domainservices.WebAuthnService
+	EmailVerificationService domainservices.EmailVerificationService
+	PasswordResetService     domainservices.PasswordResetService
+	SigningKeyService        domainservices.SigningKeyService
+}
+
+// New builds the services from cfg and loads the signing keys. Call Close
+// when done so queued mail is delivered.
+func New(cfg *config.Config, db *gorm.DB) (*App, error) {
+	staticKeys, err := loadStaticKeys(&cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWT signing key: %w", err)
+	}
+	keyring := security.NewKeyring(staticKeys[0], staticKeys[1:]...)
+
+	jwtManager, err := security.NewJWTManager(
+		keyring,
+		cfg.JWT.AccessTokenTTL,
+		cfg.JWT.RefreshTokenTTL,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize JWT manager: %w", err)
+	}
+
+	passwordManager := security.NewPasswordManager()
+	totpManager := security.NewTOTPManager(cfg.MFA.Issuer)
+
+	encryptionSecret := cfg.Security.EncryptionSecret
+	if encryptionSecret == "" {
+		encryptionSecret = cfg.JWT.Secret
+	}
+	encrypter, err := security.NewEncrypter(encryptionSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize encryption: %w", err)
+	}
+
+	mfaChallengeTTL, err := time.ParseDuration(cfg.MFA.ChallengeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MFA challenge TTL: %w", err)
+	}
+
+	webAuthnChallengeTTL, err := time.ParseDuration(cfg.WebAuthn.ChallengeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebAuthn challenge TTL: %w", err)
+	}
+	relyingParty := security.NewWebAuthnRelyingParty(
+		cfg.WebAuthn.RPID,
+		cfg.WebAuthn.RPName,
+		SplitList(cfg.WebAuthn.Origins),
+	)
+
+	emailVerificationTTL, err := time.ParseDuration(cfg.Account.EmailVerificationTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email verification TTL: %w", err)
+	}
+
+	passwordResetTTL, err := time.ParseDuration(cfg.Account.PasswordResetTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid password reset TTL: %w", err)
+	}
+
+	inviteTTL, err := time.ParseDuration(cfg.Account.InviteTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid invite TTL: %w", err)
+	}
+
+	lockoutPolicy, err := newLockoutPolicy(&cfg.Lockout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lockout configuration: %w", err)
+	}
+
+	mailer, templates, err := newMailer(&cfg.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mailer: %w", err)
+	}
+
+	// Initialize repositories
+	userRepo := repositories.NewUserRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	permissionRepo := repositories.NewPermissionRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	securityEventRepo := repositories.NewSecurityEventRepository(db)
+	signingKeyRepo := repositories.NewSigningKeyRepository(db)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository(db)
+	webAuthnCredentialRepo := repositories.NewWebAuthnCredentialRepository(db)
+	webAuthnChallengeRepo := repositories.NewWebAuthnChallengeRepository(db)
+	passwordResetTokenRepo := repositories.NewPasswordResetTokenRepository(db)
+	ipLoginFailureRepo := repositories.NewIPLoginFailureRepository(db)
+
+	var revokedTokenRepo domainrepos.RevokedTokenRepository
+	switch cfg.JWT.RevocationStore {
+	case "memory":
+		revokedTokenRepo = repositories.NewMemoryRevokedTokenRepository()
+	case "postgres":
+		revokedTokenRepo = repositories.NewRevokedTokenRepository(db)
+	default:
+		return nil, fmt.Errorf("unknown token revocation store: %s", cfg.JWT.RevocationStore)
+	}
+
+	signingKeyService, err := newSigningKeyService(&cfg.JWT, signingKeyRepo, keyring, encrypter, staticKeys, jwtManager)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize signing keys: %w", err)
+	}
+
+	if err := signingKeyService.SyncKeys(); err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	// Initialize services
+	mfaService := services.NewMFAService(
+		userRepo,
+		recoveryCodeRepo,
+		securityEventRepo,
+		encrypter,
+		totpManager,
+		passwordManager,
+	)
+	webAuthnService := services.NewWebAuthnService(
+		userRepo,
+		webAuthnCredentialRepo,
+		webAuthnChallengeRepo,
+		securityEventRepo,
+		relyingParty,
+		webAuthnChallengeTTL,
+	)
+	emailVerificationService := services.NewEmailVerificationService(
+		userRepo,
+		revokedTokenRepo,
+		jwtManager,
+		mailer,
+		templates,
+		emailVerificationTTL,
+		cfg.Account.EmailVerificationURL,
+	)
+	loginAttemptService := services.NewLoginAttemptService(
+		userRepo,
+		ipLoginFailureRepo,
+		securityEventRepo,
+		lockoutPolicy,
+	)
+	authService := services.NewAuthService(
+		userRepo,
+		roleRepo,
+		revokedTokenRepo,
+		refreshTokenRepo,
+		sessionRepo,
+		securityEventRepo,
+		jwtManager,
+		passwordManager,
+		mfaService,
+		webAuthnService,
+		emailVerificationService,
+		loginAttemptService,
+		mfaChallengeTTL,
+		cfg.Account.RequireEmailVerification,
+	)
+	permissionService := services.NewPermissionService(permissionRepo, userRepo)
+	roleService := services.NewRoleService(roleRepo, permissionRepo)
+	sessionService := services.NewSessionService(sessionRepo, refreshTokenRepo)
+	passwordResetService := services.NewPasswordResetService(
+		userRepo,
+		passwordResetTokenRepo,
+		securityEventRepo,
+		sessionService,
+		passwordManager,
+		mailer,
+		templates,
+		passwordResetTTL,
+		cfg.Account.PasswordResetURL,
+		inviteTTL,
+		cfg.Account.InviteURL,
+	)
+	userService := services.NewUserService(
+		userRepo,
+		roleRepo,
+		securityEventRepo,
+		passwordManager,
+		sessionService,
+		emailVerificationService,
+		passwordResetService,
+	)
+
+	return &App{
+		Config: cfg,
+		DB:     db,
+
+		Keyring:         keyring,
+		JWTManager:      jwtManager,
+		PasswordManager: passwordManager,
+		Mailer:          mailer,
+		LockoutPolicy:   lockoutPolicy,
+
+		UserRepo:               userRepo,
+		RoleRepo:               roleRepo,
+		PermissionRepo:         permissionRepo,
+		RevokedTokenRepo:       revokedTokenRepo,
+		RefreshTokenRepo:       refreshTokenRepo,
+		SessionRepo:            sessionRepo,
+		SecurityEventRepo:      securityEventRepo,
+		WebAuthnChallengeRepo:  webAuthnChallengeRepo,
+		PasswordResetTokenRepo: passwordResetTokenRepo,
+		IPLoginFailureRepo:     ipLoginFailureRepo,
+
+		AuthService:              authService,
+		UserService:              userService,
+		RoleService:              roleService,
+		PermissionService:        permissionService,
+		RBACService:              services.NewRBACService(roleRepo, permissionRepo),
+		SessionService:           sessionService,
+		MFAService:               mfaService,
+		WebAuthnService:          webAuthnService,
+		EmailVerificationService: emailVerificationService,
+		PasswordResetService:     passwordResetService,
+		SigningKeyService:        signingKeyService,
+	}, nil
+}
+
+// Close waits for queued mail to be sent.
+func (a *App) Close() {
+	if queue, ok := a.Mailer.(*mail.Queue); ok {
+		queue.Close()
+	}
+}
+
+// Shutdown waits for queued mail to be sent until ctx is done and drops
+// what is left then.
+func (a *App) Shutdown(ctx context.Context) error {
+	if queue, ok := a.Mailer.(*mail.Queue); ok {
+		return queue.Shutdown(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"auth-system/internal/application/dto"
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadRBACManifest reads a manifest file in YAML or JSON.
+func LoadRBACManifest(path string) (*dto.RBACManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRBACManifest(data)
+}
+
+// ParseRBACManifest decodes a manifest in YAML or JSON, which is valid
+// YAML. Unknown fields are rejected so typos do not go unnoticed.
+func ParseRBACManifest(data []byte) (*dto.RBACManifest, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var manifest dto.RBACManifest
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid RBAC manifest: %w", err)
+	}
+	return &manifest, nil
+}
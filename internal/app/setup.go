@@ -0,0 +1,156 @@
+package app
+
+import (
+	"auth-system/internal/application/services"
+	"auth-system/internal/config"
+	domainrepos "auth-system/internal/domain/repositories"
+	domainservices "auth-system/internal/domain/services"
+	"auth-system/internal/infrastructure/mail"
+	"auth-system/internal/infrastructure/security"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// loadStaticKeys returns the configured signing key followed by previous
+// secrets that are still accepted for verification. The signing key is the
+// PEM private key when one is configured and the HS256 secret otherwise.
+func loadStaticKeys(cfg *config.JWTConfig) ([]*security.SigningKey, error) {
+	var primary *security.SigningKey
+	if cfg.PrivateKeyPath != "" {
+		key, err := security.LoadSigningKeyFile(cfg.KeyID, cfg.PrivateKeyPath, cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		primary = key
+	} else {
+		if cfg.Algorithm != "" && cfg.Algorithm != "HS256" {
+			return nil, fmt.Errorf("JWT_ALGORITHM %s requires JWT_PRIVATE_KEY_PATH", cfg.Algorithm)
+		}
+		primary = security.NewHMACSigningKey(cfg.KeyID, []byte(cfg.Secret))
+	}
+
+	keys := []*security.SigningKey{primary}
+	for _, secret := range SplitList(cfg.PreviousSecrets) {
+		keys = append(keys, security.NewHMACSigningKey("", []byte(secret)))
+	}
+
+	return keys, nil
+}
+
+// SplitList parses a comma-separated setting, skipping empty entries.
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func newSigningKeyService(
+	cfg *config.JWTConfig,
+	signingKeyRepo domainrepos.SigningKeyRepository,
+	keyring *security.Keyring,
+	encrypter *security.Encrypter,
+	staticKeys []*security.SigningKey,
+	jwtManager *security.JWTManager,
+) (domainservices.SigningKeyService, error) {
+	algorithm := cfg.RotationAlgorithm
+	if algorithm == "" {
+		algorithm = staticKeys[0].Method.Alg()
+	}
+
+	rotationInterval, err := time.ParseDuration(cfg.KeyRotationInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key rotation interval: %w", err)
+	}
+
+	// Retired keys must outlive every token they signed.
+	verifyWindow := max(jwtManager.AccessTokenTTL(), jwtManager.RefreshTokenTTL())
+
+	return services.NewSigningKeyService(
+		signingKeyRepo,
+		keyring,
+		encrypter,
+		staticKeys,
+		algorithm,
+		verifyWindow,
+		rotationInterval,
+	), nil
+}
+
+// newMailer builds the configured mail driver, wrapped in an async queue
+// with retries unless MAIL_QUEUE_SIZE is 0.
+func newMailer(cfg *config.MailConfig) (mail.Mailer, *mail.Templates, error) {
+	templateFS := mail.DefaultTemplates()
+	if cfg.TemplatesDir != "" {
+		templateFS = os.DirFS(cfg.TemplatesDir)
+	}
+	templates := mail.NewTemplates(templateFS, cfg.DefaultLocale)
+
+	var mailer mail.Mailer
+	switch cfg.Driver {
+	case "smtp":
+		smtpMailer, err := mail.NewSMTPMailer(cfg.From, mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			Security: cfg.SMTPSecurity,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		mailer = smtpMailer
+	case "file":
+		fileMailer, err := mail.NewFileMailer(cfg.From, cfg.FileDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		mailer = fileMailer
+	case "log":
+		mailer = mail.NewLogMailer(cfg.From)
+	default:
+		return nil, nil, fmt.Errorf("unknown mail driver: %s", cfg.Driver)
+	}
+
+	if cfg.QueueSize == 0 {
+		return mailer, templates, nil
+	}
+
+	retryBackoff, err := time.ParseDuration(cfg.RetryBackoff)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid mail retry backoff: %w", err)
+	}
+
+	queue := mail.NewQueue(mailer, cfg.QueueSize, cfg.QueueWorkers, cfg.MaxAttempts, retryBackoff)
+	return queue, templates, nil
+}
+
+func newLockoutPolicy(cfg *config.LockoutConfig) (services.LockoutPolicy, error) {
+	baseDuration, err := time.ParseDuration(cfg.BaseDuration)
+	if err != nil {
+		return services.LockoutPolicy{}, fmt.Errorf("invalid lockout base duration: %w", err)
+	}
+
+	maxDuration, err := time.ParseDuration(cfg.MaxDuration)
+	if err != nil {
+		return services.LockoutPolicy{}, fmt.Errorf("invalid lockout max duration: %w", err)
+	}
+
+	resetAfter, err := time.ParseDuration(cfg.ResetAfter)
+	if err != nil {
+		return services.LockoutPolicy{}, fmt.Errorf("invalid lockout reset interval: %w", err)
+	}
+
+	return services.LockoutPolicy{
+		AccountThreshold: cfg.AccountThreshold,
+		IPThreshold:      cfg.IPThreshold,
+		BaseDuration:     baseDuration,
+		MaxDuration:      maxDuration,
+		ResetAfter:       resetAfter,
+	}, nil
+}
@@ -1,23 +1,50 @@
 package dto
 
+// ClientInfo describes the device a request came from. It is filled in by
+// the handlers rather than bound from the request body.
+type ClientInfo struct {
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
+	// Locale is the first language of the Accept-Language header.
+	Locale string `json:"-"`
+}
+
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=6"`
+	DeviceName string `json:"device_name"`
+	ClientInfo
 }
 
 type RegisterRequest struct {
-	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
-	FirstName string `json:"first_name" binding:"required"`
-	LastName  string `json:"last_name" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=6"`
+	FirstName  string `json:"first_name" binding:"required"`
+	LastName   string `json:"last_name" binding:"required"`
+	Locale     string `json:"locale" binding:"omitempty,max=16"`
+	DeviceName string `json:"device_name"`
+	ClientInfo
 }
 
+// AuthResponse carries either a token pair or, when the account has a second
+// factor, an MFA challenge token to exchange at /auth/mfa/verify using one
+// of MFAMethods. Registration returns no tokens when the email must be
+// verified before the first login.
 type AuthResponse struct {
-	AccessToken  string       `json:"access_token"`
-	RefreshToken string       `json:"refresh_token"`
-	User         UserResponse `json:"user"`
+	AccessToken               string        `json:"access_token,omitempty"`
+	RefreshToken              string        `json:"refresh_token,omitempty"`
+	User                      *UserResponse `json:"user,omitempty"`
+	MFARequired               bool          `json:"mfa_required,omitempty"`
+	MFAToken                  string        `json:"mfa_token,omitempty"`
+	MFAMethods                []string      `json:"mfa_methods,omitempty"`
+	EmailVerificationRequired bool          `json:"email_verification_required,omitempty"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
+	ClientInfo
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
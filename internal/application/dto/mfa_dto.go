@@ -0,0 +1,31 @@
+package dto
+
+type MFAEnrollRequest struct {
+	// Code from the current authenticator, required when re-enrolling.
+	Code string `json:"code"`
+}
+
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFAVerifyRequest completes a login with a TOTP code, one of the recovery
+// codes or a WebAuthn assertion obtained from /auth/webauthn/mfa/begin.
+type MFAVerifyRequest struct {
+	MFAToken     string             `json:"mfa_token" binding:"required"`
+	Code         string             `json:"code" binding:"required_without_all=RecoveryCode WebAuthn"`
+	RecoveryCode string             `json:"recovery_code"`
+	WebAuthn     *WebAuthnAssertion `json:"webauthn"`
+	DeviceName   string             `json:"device_name"`
+	ClientInfo
+}
+
+// RecoveryCodesResponse is the only time recovery codes are shown in clear.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+// RBACManifest declares the permission catalog and the roles with their
+// grants. It is read from YAML or JSON. Roles grant permissions and
+// inherit from other roles by name; a granted permission or parent role
+// must be declared in the manifest or already exist.
+// Roles and permissions missing from the manifest are left alone.
+type RBACManifest struct {
+	Permissions []PermissionRequest `json:"permissions" yaml:"permissions" binding:"dive"`
+	Roles       []ManifestRole      `json:"roles" yaml:"roles" binding:"dive"`
+}
+
+// ManifestRole is a role with the complete lists of permissions it grants
+// and denies directly and of roles it inherits from.
+type ManifestRole struct {
+	Name        string   `json:"name" yaml:"name" binding:"required,max=100"`
+	Description string   `json:"description" yaml:"description,omitempty" binding:"max=255"`
+	Inherits    []string `json:"inherits,omitempty" yaml:"inherits,omitempty"`
+	Permissions []string `json:"permissions" yaml:"permissions"`
+	Denies      []string `json:"denies,omitempty" yaml:"denies,omitempty"`
+}
+
+// RBACPlan lists the changes applying a manifest makes to the catalog.
+type RBACPlan struct {
+	HasChanges        bool         `json:"has_changes"`
+	CreatePermissions []string     `json:"create_permissions"`
+	UpdatePermissions []string     `json:"update_permissions"`
+	CreateRoles       []string     `json:"create_roles"`
+	UpdateRoles       []string     `json:"update_roles"`
+	Grant             []RoleGrant  `json:"grant"`
+	Revoke            []RoleGrant  `json:"revoke"`
+	Deny              []RoleGrant  `json:"deny"`
+	Undeny            []RoleGrant  `json:"undeny"`
+	AddParents        []RoleParent `json:"add_parents"`
+	RemoveParents     []RoleParent `json:"remove_parents"`
+}
+
+type RoleGrant struct {
+	Role       string `json:"role"`
+	Permission string `json:"permission"`
+}
+
+type RoleParent struct {
+	Role   string `json:"role"`
+	Parent string `json:"parent"`
+}
@@ -14,3 +14,12 @@ type PaginatedResponse struct {
 	PerPage    int         `json:"per_page"`
 	TotalPages int         `json:"total_pages"`
 }
+
+// CursorPaginatedResponse is a page of a keyset listing. The cursors are
+// opaque and empty when there is no page in that direction.
+type CursorPaginatedResponse struct {
+	Data       interface{} `json:"data"`
+	PerPage    int         `json:"per_page"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+}
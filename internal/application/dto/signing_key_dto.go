@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type SigningKeyResponse struct {
+	KID         string     `json:"kid"`
+	Algorithm   string     `json:"algorithm"`
+	Status      string     `json:"status"`
+	ActivatedAt time.Time  `json:"activated_at"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty"`
+	VerifyUntil *time.Time `json:"verify_until,omitempty"`
+}
@@ -1,19 +1,126 @@
 package dto
 
+import "time"
+
 type UserResponse struct {
-	ID        uint           `json:"id"`
-	Email     string         `json:"email"`
-	FirstName string         `json:"first_name"`
-	LastName  string         `json:"last_name"`
-	IsActive  bool           `json:"is_active"`
-	Roles     []RoleResponse `json:"roles"`
+	ID                     uint           `json:"id"`
+	Email                  string         `json:"email"`
+	FirstName              string         `json:"first_name"`
+	LastName               string         `json:"last_name"`
+	Locale                 string         `json:"locale"`
+	IsActive               bool           `json:"is_active"`
+	EmailVerified          bool           `json:"email_verified"`
+	MFAEnabled             bool           `json:"mfa_enabled"`
+	RecoveryCodesRemaining int            `json:"recovery_codes_remaining"`
+	LockedUntil            *time.Time     `json:"locked_until,omitempty"`
+	DeletedAt              *time.Time     `json:"deleted_at,omitempty"`
+	Roles                  []RoleResponse `json:"roles"`
+}
+
+// ListUsersRequest holds the query parameters of the admin user listing.
+// Dates are "2006-01-02" or RFC 3339; a plain date in created_to includes
+// the whole day. Sort is a field name, prefixed with "-" for descending.
+// Pagination "cursor", or an After/Before cursor, selects keyset paging
+// instead of page numbers.
+type ListUsersRequest struct {
+	Page        int    `form:"page" binding:"omitempty,min=1"`
+	PerPage     int    `form:"per_page" binding:"omitempty,min=1,max=100"`
+	Search      string `form:"search" binding:"max=255"`
+	Role        string `form:"role"`
+	IsActive    *bool  `form:"is_active"`
+	CreatedFrom string `form:"created_from"`
+	CreatedTo   string `form:"created_to"`
+	Sort        string `form:"sort"`
+	// Deleted lists soft-deleted users instead.
+	Deleted    bool   `form:"deleted"`
+	Pagination string `form:"pagination" binding:"omitempty,oneof=page cursor"`
+	After      string `form:"after"`
+	Before     string `form:"before"`
+}
+
+// CreateUserRequest creates an account on behalf of someone. Without a
+// password, SendInvite must be set so the user can choose one.
+type CreateUserRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"omitempty,min=6"`
+	FirstName string `json:"first_name" binding:"required"`
+	LastName  string `json:"last_name" binding:"required"`
+	Locale    string `json:"locale" binding:"omitempty,max=16"`
+	// RoleIDs defaults to the "user" role.
+	RoleIDs       []uint `json:"role_ids"`
+	EmailVerified bool   `json:"email_verified"`
+	SendInvite    bool   `json:"send_invite"`
+}
+
+type UserStatusRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
 }
 
 type RoleResponse struct {
 	ID          uint                 `json:"id"`
 	Name        string               `json:"name"`
 	Description string               `json:"description"`
+	Parents     []RoleReference      `json:"parents,omitempty"`
 	Permissions []PermissionResponse `json:"permissions,omitempty"`
+	// DeniedPermissions override every grant, of this role or another.
+	DeniedPermissions []PermissionResponse `json:"denied_permissions,omitempty"`
+	// InheritedPermissions are granted through parent roles, and not
+	// directly; likewise InheritedDeniedPermissions. They are only filled
+	// in by the role endpoints.
+	InheritedPermissions       []InheritedPermissionResponse `json:"inherited_permissions,omitempty"`
+	InheritedDeniedPermissions []InheritedPermissionResponse `json:"inherited_denied_permissions,omitempty"`
+}
+
+type RoleReference struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type InheritedPermissionResponse struct {
+	PermissionResponse
+	// InheritedFrom is the nearest ancestor role granting, or denying, the
+	// permission.
+	InheritedFrom string `json:"inherited_from"`
+}
+
+type CreateRoleRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=255"`
+	// PermissionIDs are granted to the new role.
+	PermissionIDs []uint `json:"permission_ids"`
+	// ParentIDs are the roles the new role inherits from.
+	ParentIDs []uint `json:"parent_ids"`
+}
+
+type UpdateRoleRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+// RoleParentsRequest replaces the parents of a role; an empty list removes
+// them all.
+type RoleParentsRequest struct {
+	ParentIDs []uint `json:"parent_ids"`
+}
+
+type RolePermissionsRequest struct {
+	PermissionIDs []uint `json:"permission_ids" binding:"required,min=1"`
+}
+
+// DeniedPermissionsRequest denies permissions to a role or a user.
+type DeniedPermissionsRequest struct {
+	PermissionIDs []uint `json:"permission_ids" binding:"required,min=1"`
+}
+
+// PermissionRequest describes a permission named "<resource>.<action>".
+type PermissionRequest struct {
+	Resource    string `json:"resource" yaml:"resource" binding:"required,max=100"`
+	Action      string `json:"action" yaml:"action" binding:"required,max=100"`
+	Description string `json:"description" yaml:"description,omitempty" binding:"max=255"`
+}
+
+type RegisterPermissionsRequest struct {
+	Permissions []PermissionRequest `json:"permissions" binding:"required,min=1,dive"`
 }
 
 type PermissionResponse struct {
@@ -24,9 +131,24 @@ type PermissionResponse struct {
 	Description string `json:"description"`
 }
 
+// PermissionDecision explains the outcome of a permission check.
+type PermissionDecision struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+	Allowed  bool   `json:"allowed"`
+	// Permission is the grant or denial that decided, with its effect and
+	// the role carrying it; all three are empty when nothing matched, and
+	// Role is empty for a denial made to the user directly.
+	Permission string `json:"permission,omitempty"`
+	Effect     string `json:"effect,omitempty"`
+	Role       string `json:"role,omitempty"`
+	Reason     string `json:"reason"`
+}
+
 type UpdateUserRequest struct {
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
+	Locale    string `json:"locale" binding:"omitempty,max=16"`
 }
 
 type ChangePasswordRequest struct {
@@ -0,0 +1,105 @@
+package dto
+
+import "time"
+
+// The option and credential types mirror the WebAuthn JSON serialization,
+// with binary values encoded as base64url, so they can be passed straight to
+// navigator.credentials after decoding.
+
+type WebAuthnRelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WebAuthnUserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type WebAuthnCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type WebAuthnCreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     WebAuthnRelyingPartyEntity     `json:"rp"`
+	User                   WebAuthnUserEntity             `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                         `json:"attestation"`
+}
+
+type WebAuthnRequestOptions struct {
+	Challenge        string                         `json:"challenge"`
+	Timeout          int64                          `json:"timeout"`
+	RPID             string                         `json:"rpId"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+type WebAuthnAttestationResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON" binding:"required"`
+	AttestationObject string   `json:"attestationObject" binding:"required"`
+	Transports        []string `json:"transports"`
+}
+
+type WebAuthnRegistrationCredential struct {
+	ID       string                      `json:"id" binding:"required"`
+	Type     string                      `json:"type" binding:"required,eq=public-key"`
+	Response WebAuthnAttestationResponse `json:"response" binding:"required"`
+}
+
+type WebAuthnRegistrationRequest struct {
+	Name       string                         `json:"name" binding:"max=100"`
+	Credential WebAuthnRegistrationCredential `json:"credential" binding:"required"`
+}
+
+type WebAuthnAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+	AuthenticatorData string `json:"authenticatorData" binding:"required"`
+	Signature         string `json:"signature" binding:"required"`
+	UserHandle        string `json:"userHandle"`
+}
+
+type WebAuthnAssertion struct {
+	ID       string                    `json:"id" binding:"required"`
+	Type     string                    `json:"type" binding:"required,eq=public-key"`
+	Response WebAuthnAssertionResponse `json:"response" binding:"required"`
+}
+
+// PasskeyLoginRequest completes a passwordless login.
+type PasskeyLoginRequest struct {
+	Credential WebAuthnAssertion `json:"credential" binding:"required"`
+	DeviceName string            `json:"device_name"`
+	ClientInfo
+}
+
+// MFAWebAuthnBeginRequest requests assertion options for the second factor
+// of a pending login.
+type MFAWebAuthnBeginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+type WebAuthnCredentialResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	AAGUID     string     `json:"aaguid"`
+	Transports []string   `json:"transports"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
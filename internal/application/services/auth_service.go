@@ -8,35 +8,74 @@ import (
 	"auth-system/internal/infrastructure/security"
 	"auth-system/pkg/errors"
 	"fmt"
+	"log"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type authService struct {
-	userRepo        repositories.UserRepository
-	roleRepo        repositories.RoleRepository
-	jwtManager      *security.JWTManager
-	passwordManager *security.PasswordManager
+	userRepo                 repositories.UserRepository
+	roleRepo                 repositories.RoleRepository
+	revokedTokenRepo         repositories.RevokedTokenRepository
+	refreshTokenRepo         repositories.RefreshTokenRepository
+	sessionRepo              repositories.SessionRepository
+	securityEventRepo        repositories.SecurityEventRepository
+	jwtManager               *security.JWTManager
+	passwordManager          *security.PasswordManager
+	mfaService               services.MFAService
+	webAuthnService          services.WebAuthnService
+	emailVerificationService services.EmailVerificationService
+	loginAttemptService      services.LoginAttemptService
+	mfaChallengeTTL          time.Duration
+	requireVerifiedEmail     bool
 }
 
 func NewAuthService(
 	userRepo repositories.UserRepository,
 	roleRepo repositories.RoleRepository,
+	revokedTokenRepo repositories.RevokedTokenRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	sessionRepo repositories.SessionRepository,
+	securityEventRepo repositories.SecurityEventRepository,
 	jwtManager *security.JWTManager,
 	passwordManager *security.PasswordManager,
+	mfaService services.MFAService,
+	webAuthnService services.WebAuthnService,
+	emailVerificationService services.EmailVerificationService,
+	loginAttemptService services.LoginAttemptService,
+	mfaChallengeTTL time.Duration,
+	requireVerifiedEmail bool,
 ) services.AuthService {
 	return &authService{
-		userRepo:        userRepo,
-		roleRepo:        roleRepo,
-		jwtManager:      jwtManager,
-		passwordManager: passwordManager,
+		userRepo:                 userRepo,
+		roleRepo:                 roleRepo,
+		revokedTokenRepo:         revokedTokenRepo,
+		refreshTokenRepo:         refreshTokenRepo,
+		sessionRepo:              sessionRepo,
+		securityEventRepo:        securityEventRepo,
+		jwtManager:               jwtManager,
+		passwordManager:          passwordManager,
+		mfaService:               mfaService,
+		webAuthnService:          webAuthnService,
+		emailVerificationService: emailVerificationService,
+		loginAttemptService:      loginAttemptService,
+		mfaChallengeTTL:          mfaChallengeTTL,
+		requireVerifiedEmail:     requireVerifiedEmail,
 	}
 }
 
 func (s *authService) Login(req *dto.LoginRequest) (*dto.AuthResponse, error) {
+	if err := s.loginAttemptService.CheckIP(req.IPAddress); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByEmail(req.Email)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
+			if err := s.loginAttemptService.RecordFailure(nil, req.IPAddress); err != nil {
+				return nil, err
+			}
 			return nil, errors.NewValidationError("Invalid credentials")
 		}
 		return nil, fmt.Errorf("Failed to get user: %w", err)
@@ -46,20 +85,219 @@ func (s *authService) Login(req *dto.LoginRequest) (*dto.AuthResponse, error) {
 		return nil, errors.NewValidationError("Account is deactivated")
 	}
 
+	// Checked before the password so a locked account cannot be probed.
+	if err := s.loginAttemptService.CheckUser(user); err != nil {
+		return nil, err
+	}
+
 	if err := s.passwordManager.CheckPassword(user.Password, req.Password); err != nil {
+		if err := s.loginAttemptService.RecordFailure(user, req.IPAddress); err != nil {
+			return nil, err
+		}
 		return nil, errors.NewValidationError("Invalid credentials")
 	}
 
-	accessToken, refreshToken, err := s.jwtManager.GenerateTokenPair(user.ID, user.Email)
+	if err := s.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+
+	methods, err := s.mfaMethods(user)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to generate tokens: %w", err)
+		return nil, err
 	}
 
-	return &dto.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         s.mapUserToResponse(user),
-	}, nil
+	if len(methods) > 0 {
+		mfaToken, _, err := s.jwtManager.GenerateMFAToken(user.ID, user.Email, s.mfaChallengeTTL)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to generate MFA challenge: %w", err)
+		}
+
+		return &dto.AuthResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			MFAMethods:  methods,
+		}, nil
+	}
+
+	if err := s.loginAttemptService.RecordSuccess(user); err != nil {
+		return nil, err
+	}
+
+	session, err := s.startSession(user, req.DeviceName, req.ClientInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, session)
+}
+
+func (s *authService) VerifyMFA(req *dto.MFAVerifyRequest) (*dto.AuthResponse, error) {
+	claims, user, err := s.validateMFAChallenge(req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.WebAuthn != nil {
+		_, err = s.webAuthnService.FinishAuthentication(req.WebAuthn, user.ID)
+	} else {
+		err = s.mfaService.VerifySecondFactor(user, req.Code, req.RecoveryCode)
+	}
+	if err != nil {
+		// Wrong codes count towards the lockout like wrong passwords.
+		if _, rejected := err.(*errors.AppError); rejected {
+			if err := s.loginAttemptService.RecordFailure(user, req.IPAddress); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	// A challenge can only be completed once.
+	if err := s.revokedTokenRepo.Revoke(&entities.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    user.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}); err != nil {
+		return nil, fmt.Errorf("Failed to consume MFA challenge: %w", err)
+	}
+
+	if err := s.loginAttemptService.RecordSuccess(user); err != nil {
+		return nil, err
+	}
+
+	session, err := s.startSession(user, req.DeviceName, req.ClientInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, session)
+}
+
+func (s *authService) BeginMFAWebAuthn(req *dto.MFAWebAuthnBeginRequest) (*dto.WebAuthnRequestOptions, error) {
+	_, user, err := s.validateMFAChallenge(req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.webAuthnService.BeginAuthentication(user.ID, false)
+}
+
+func (s *authService) BeginPasskeyLogin() (*dto.WebAuthnRequestOptions, error) {
+	return s.webAuthnService.BeginAuthentication(0, true)
+}
+
+// FinishPasskeyLogin signs in with a discoverable credential alone. The
+// assertion must carry user verification, so it satisfies both factors.
+func (s *authService) FinishPasskeyLogin(req *dto.PasskeyLoginRequest) (*dto.AuthResponse, error) {
+	if err := s.loginAttemptService.CheckIP(req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	user, err := s.webAuthnService.FinishAuthentication(&req.Credential, 0)
+	if err != nil {
+		// Rejected assertions count towards the lockout like wrong
+		// passwords, against the passkey's owner when it is known.
+		if _, rejected := err.(*errors.AppError); rejected {
+			if err := s.loginAttemptService.RecordFailure(user, req.IPAddress); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, errors.NewValidationError("Account is deactivated")
+	}
+
+	// A locked account stays locked whichever way the user signs in.
+	if err := s.loginAttemptService.CheckUser(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.loginAttemptService.RecordSuccess(user); err != nil {
+		return nil, err
+	}
+
+	session, err := s.startSession(user, req.DeviceName, req.ClientInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, session)
+}
+
+// validateMFAChallenge checks an MFA challenge token issued by Login and
+// returns its claims and user. It does not consume the challenge.
+func (s *authService) validateMFAChallenge(mfaToken string) (*security.Claims, *entities.User, error) {
+	invalid := errors.NewUnauthorizedError("Invalid or expired MFA challenge")
+
+	claims, err := s.jwtManager.ValidateToken(mfaToken)
+	if err != nil || claims.Type != "mfa" || claims.ID == "" {
+		return nil, nil, invalid
+	}
+
+	revoked, err := s.revokedTokenRepo.IsRevoked(claims.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, nil, invalid
+	}
+
+	user, err := s.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, invalid
+		}
+		return nil, nil, fmt.Errorf("Failed to get user: %w", err)
+	}
+
+	if !user.IsActive {
+		return nil, nil, errors.NewValidationError("Account is deactivated")
+	}
+
+	if err := s.loginAttemptService.CheckUser(user); err != nil {
+		return nil, nil, err
+	}
+
+	methods, err := s.mfaMethods(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(methods) == 0 {
+		return nil, nil, invalid
+	}
+
+	return claims, user, nil
+}
+
+func (s *authService) checkEmailVerified(user *entities.User) error {
+	if s.requireVerifiedEmail && !user.EmailVerified {
+		return errors.NewForbiddenError("Email address is not verified")
+	}
+	return nil
+}
+
+// mfaMethods lists the second factors the user can complete a login with.
+func (s *authService) mfaMethods(user *entities.User) ([]string, error) {
+	var methods []string
+	if user.MFAEnabled {
+		methods = append(methods, "totp", "recovery_code")
+	}
+
+	hasPasskeys, err := s.webAuthnService.HasCredentials(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hasPasskeys {
+		methods = append(methods, "webauthn")
+	}
+
+	return methods, nil
 }
 
 func (s *authService) Register(req *dto.RegisterRequest) (*dto.AuthResponse, error) {
@@ -74,11 +312,17 @@ func (s *authService) Register(req *dto.RegisterRequest) (*dto.AuthResponse, err
 		return nil, fmt.Errorf("Failed to hash password: %w", err)
 	}
 
+	locale := req.Locale
+	if locale == "" {
+		locale = req.ClientInfo.Locale
+	}
+
 	user := &entities.User{
 		Email:     req.Email,
 		Password:  hashedPassword,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
+		Locale:    locale,
 		IsActive:  true,
 	}
 
@@ -93,20 +337,30 @@ func (s *authService) Register(req *dto.RegisterRequest) (*dto.AuthResponse, err
 		s.userRepo.Update(user)
 	}
 
-	accessToken, refreshToken, err := s.jwtManager.GenerateTokenPair(user.ID, user.Email)
+	// The account exists at this point; a failed delivery can be retried
+	// through the resend endpoint.
+	if err := s.emailVerificationService.SendVerification(user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	if s.requireVerifiedEmail {
+		userResponse := s.mapUserToResponse(user)
+		return &dto.AuthResponse{
+			User:                      &userResponse,
+			EmailVerificationRequired: true,
+		}, nil
+	}
+
+	session, err := s.startSession(user, req.DeviceName, req.ClientInfo)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to generate tokens: %w", err)
+		return nil, err
 	}
 
-	return &dto.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         s.mapUserToResponse(user),
-	}, nil
+	return s.issueTokens(user, session)
 }
 
-func (s *authService) RefreshToken(refreshToken string) (*dto.AuthResponse, error) {
-	claims, err := s.jwtManager.ValidateToken(refreshToken)
+func (s *authService) RefreshToken(req *dto.RefreshTokenRequest) (*dto.AuthResponse, error) {
+	claims, err := s.jwtManager.ValidateToken(req.RefreshToken)
 	if err != nil {
 		return nil, errors.NewValidationError("Invalid refresh token")
 	}
@@ -115,6 +369,55 @@ func (s *authService) RefreshToken(refreshToken string) (*dto.AuthResponse, erro
 		return nil, errors.NewValidationError("Invalid token type")
 	}
 
+	if claims.ID == "" {
+		return nil, errors.NewValidationError("Invalid refresh token")
+	}
+
+	revoked, err := s.revokedTokenRepo.IsRevoked(claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return nil, errors.NewUnauthorizedError("Refresh token has been revoked")
+	}
+
+	stored, err := s.refreshTokenRepo.GetByJTI(claims.ID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewValidationError("Invalid refresh token")
+		}
+		return nil, fmt.Errorf("Failed to get refresh token: %w", err)
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, errors.NewUnauthorizedError("Refresh token has been revoked")
+	}
+
+	session, err := s.sessionRepo.GetByID(claims.SessionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewUnauthorizedError("Session has ended")
+		}
+		return nil, fmt.Errorf("Failed to get session: %w", err)
+	}
+
+	if !session.IsActive() || session.FamilyID != stored.FamilyID {
+		return nil, errors.NewUnauthorizedError("Session has ended")
+	}
+
+	consumed, err := s.refreshTokenRepo.MarkConsumed(stored.JTI)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to consume refresh token: %w", err)
+	}
+	if !consumed {
+		// The token was already rotated: someone is replaying an old token,
+		// so the whole family must be considered compromised.
+		if err := s.handleTokenReuse(session, stored); err != nil {
+			return nil, err
+		}
+		return nil, errors.NewUnauthorizedError("Refresh token has already been used")
+	}
+
 	user, err := s.userRepo.GetByID(claims.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get user: %w", err)
@@ -124,51 +427,154 @@ func (s *authService) RefreshToken(refreshToken string) (*dto.AuthResponse, erro
 		return nil, errors.NewValidationError("Account is deactivated")
 	}
 
-	accessToken, newRefreshToken, err := s.jwtManager.GenerateTokenPair(user.ID, user.Email)
+	session.LastUsedAt = time.Now()
+	session.ExpiresAt = session.LastUsedAt.Add(s.jwtManager.RefreshTokenTTL())
+	session.UserAgent = req.UserAgent
+	session.IPAddress = req.IPAddress
+	if err := s.sessionRepo.Update(session); err != nil {
+		return nil, fmt.Errorf("Failed to update session: %w", err)
+	}
+
+	return s.issueTokens(user, session)
+}
+
+func (s *authService) startSession(user *entities.User, deviceName string, client dto.ClientInfo) (*entities.Session, error) {
+	familyID, err := security.GenerateTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate token family: %w", err)
+	}
+
+	now := time.Now()
+	session := &entities.Session{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		DeviceName: deviceName,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.jwtManager.RefreshTokenTTL()),
+	}
+
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, fmt.Errorf("Failed to create session: %w", err)
+	}
+
+	return session, nil
+}
+
+// issueTokens generates a token pair bound to the session and persists the
+// refresh token as part of the session's token family.
+func (s *authService) issueTokens(user *entities.User, session *entities.Session) (*dto.AuthResponse, error) {
+	pair, err := s.jwtManager.GenerateTokenPair(user.ID, user.Email, session.ID)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to generate tokens: %w", err)
 	}
 
+	if err := s.refreshTokenRepo.Create(&entities.RefreshToken{
+		JTI:       pair.RefreshClaims.ID,
+		FamilyID:  session.FamilyID,
+		UserID:    user.ID,
+		ExpiresAt: pair.RefreshClaims.ExpiresAt.Time,
+	}); err != nil {
+		return nil, fmt.Errorf("Failed to store refresh token: %w", err)
+	}
+
+	userResponse := s.mapUserToResponse(user)
 	return &dto.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-		User:         s.mapUserToResponse(user),
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		User:         &userResponse,
 	}, nil
 }
 
-func (s *authService) Logout(userID uint) error {
-	// In a production system, you might want to blacklist the tokens
-	// For now, we just return success
+func (s *authService) Logout(userID uint, accessToken, refreshToken string) error {
+	claims, err := s.revokeToken(userID, accessToken, "access")
+	if err != nil {
+		return err
+	}
+
+	if refreshToken != "" {
+		if _, err := s.revokeToken(userID, refreshToken, "refresh"); err != nil {
+			return err
+		}
+	}
+
+	session, err := s.sessionRepo.GetByID(claims.SessionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("Failed to get session: %w", err)
+	}
+
+	return revokeSession(s.sessionRepo, s.refreshTokenRepo, session)
+}
+
+func (s *authService) handleTokenReuse(session *entities.Session, token *entities.RefreshToken) error {
+	if err := revokeSession(s.sessionRepo, s.refreshTokenRepo, session); err != nil {
+		return err
+	}
+
+	if err := s.securityEventRepo.Create(&entities.SecurityEvent{
+		UserID:  token.UserID,
+		Type:    entities.SecurityEventRefreshTokenReuse,
+		Details: fmt.Sprintf("Consumed refresh token was presented again (session %d)", session.ID),
+	}); err != nil {
+		return fmt.Errorf("Failed to record security event: %w", err)
+	}
+
 	return nil
 }
 
+func (s *authService) revokeToken(userID uint, tokenString, tokenType string) (*security.Claims, error) {
+	claims, err := s.jwtManager.ValidateToken(tokenString)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid %s token", tokenType))
+	}
+
+	if claims.Type != tokenType || claims.UserID != userID || claims.ID == "" {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid %s token", tokenType))
+	}
+
+	expiresAt := time.Now()
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	if err := s.revokedTokenRepo.Revoke(&entities.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("Failed to revoke token: %w", err)
+	}
+
+	return claims, nil
+}
+
 func (s *authService) mapUserToResponse(user *entities.User) dto.UserResponse {
 	roles := make([]dto.RoleResponse, len(user.Roles))
-	for i, role := range user.Roles {
-		permissions := make([]dto.PermissionResponse, len(role.Permissions))
-		for j, perm := range role.Permissions {
-			permissions[j] = dto.PermissionResponse{
-				ID:          perm.ID,
-				Name:        perm.Name,
-				Resource:    perm.Resource,
-				Action:      perm.Action,
-				Description: perm.Description,
-			}
-		}
-		roles[i] = dto.RoleResponse{
-			ID:          role.ID,
-			Name:        role.Name,
-			Description: role.Description,
-			Permissions: permissions,
-		}
+	for i := range user.Roles {
+		roles[i] = mapRoleToResponse(&user.Roles[i])
+	}
+
+	var deletedAt *time.Time
+	if user.DeletedAt.Valid {
+		deletedAt = &user.DeletedAt.Time
 	}
 
 	return dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		IsActive:  user.IsActive,
-		Roles:     roles,
+		ID:                     user.ID,
+		Email:                  user.Email,
+		FirstName:              user.FirstName,
+		LastName:               user.LastName,
+		Locale:                 user.Locale,
+		IsActive:               user.IsActive,
+		EmailVerified:          user.EmailVerified,
+		MFAEnabled:             user.MFAEnabled,
+		RecoveryCodesRemaining: len(user.RecoveryCodes),
+		LockedUntil:            user.LockedUntil,
+		DeletedAt:              deletedAt,
+		Roles:                  roles,
 	}
 }
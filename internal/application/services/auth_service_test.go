@@ -0,0 +1,70 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/services"
+	"testing"
+)
+
+type loginFailure struct {
+	user *entities.User
+	ip   string
+}
+
+type fakeLoginAttemptService struct {
+	services.LoginAttemptService
+	failures []loginFailure
+}
+
+func (s *fakeLoginAttemptService) CheckIP(ipAddress string) error {
+	return nil
+}
+
+func (s *fakeLoginAttemptService) RecordFailure(user *entities.User, ipAddress string) error {
+	s.failures = append(s.failures, loginFailure{user, ipAddress})
+	return nil
+}
+
+func TestFinishPasskeyLoginRecordsFailures(t *testing.T) {
+	w := newWebAuthnTest(t)
+	loginAttempts := &fakeLoginAttemptService{}
+	auth := &authService{webAuthnService: w.service, loginAttemptService: loginAttempts}
+
+	begin := func() *dto.WebAuthnAssertion {
+		t.Helper()
+		options, err := w.service.BeginAuthentication(0, false)
+		if err != nil {
+			t.Fatalf("BeginAuthentication error = %v", err)
+		}
+		return w.authenticator.assert(t, options.Challenge, 1)
+	}
+
+	// A bad signature counts against the passkey's owner and the IP.
+	assertion := begin()
+	assertion.Response.Signature = encodeBase64URL([]byte("not a signature"))
+	req := &dto.PasskeyLoginRequest{Credential: *assertion}
+	req.IPAddress = "192.0.2.1"
+	if _, err := auth.FinishPasskeyLogin(req); err == nil {
+		t.Fatal("FinishPasskeyLogin accepted a bad signature")
+	}
+
+	// An unknown credential counts against the IP only.
+	assertion = begin()
+	assertion.ID = encodeBase64URL([]byte("unknown credential"))
+	req = &dto.PasskeyLoginRequest{Credential: *assertion}
+	req.IPAddress = "192.0.2.1"
+	if _, err := auth.FinishPasskeyLogin(req); err == nil {
+		t.Fatal("FinishPasskeyLogin accepted an unknown credential")
+	}
+
+	if len(loginAttempts.failures) != 2 {
+		t.Fatalf("recorded %d failures, want 2", len(loginAttempts.failures))
+	}
+	if got := loginAttempts.failures[0]; got.user == nil || got.user.ID != 1 || got.ip != "192.0.2.1" {
+		t.Errorf("first failure = %+v, want user 1 from 192.0.2.1", got)
+	}
+	if got := loginAttempts.failures[1]; got.user != nil || got.ip != "192.0.2.1" {
+		t.Errorf("second failure = %+v, want no user from 192.0.2.1", got)
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/domain/services"
+	"auth-system/internal/infrastructure/mail"
+	"auth-system/internal/infrastructure/security"
+	"auth-system/pkg/errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type emailVerificationService struct {
+	userRepo         repositories.UserRepository
+	revokedTokenRepo repositories.RevokedTokenRepository
+	jwtManager       *security.JWTManager
+	mailer           mail.Mailer
+	templates        *mail.Templates
+	tokenTTL         time.Duration
+	verificationURL  string
+}
+
+func NewEmailVerificationService(
+	userRepo repositories.UserRepository,
+	revokedTokenRepo repositories.RevokedTokenRepository,
+	jwtManager *security.JWTManager,
+	mailer mail.Mailer,
+	templates *mail.Templates,
+	tokenTTL time.Duration,
+	verificationURL string,
+) services.EmailVerificationService {
+	return &emailVerificationService{
+		userRepo:         userRepo,
+		revokedTokenRepo: revokedTokenRepo,
+		jwtManager:       jwtManager,
+		mailer:           mailer,
+		templates:        templates,
+		tokenTTL:         tokenTTL,
+		verificationURL:  verificationURL,
+	}
+}
+
+func (s *emailVerificationService) SendVerification(user *entities.User) error {
+	token, _, err := s.jwtManager.GenerateEmailVerificationToken(user.ID, user.Email, s.tokenTTL)
+	if err != nil {
+		return fmt.Errorf("Failed to generate verification token: %w", err)
+	}
+
+	link, err := tokenLink(s.verificationURL, token)
+	if err != nil {
+		return err
+	}
+
+	message, err := s.templates.Render("verify_email", user.Locale, user.Email, map[string]any{
+		"Name":      user.FirstName,
+		"Link":      link,
+		"ExpiresIn": s.tokenTTL,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to render verification email: %w", err)
+	}
+
+	if err := s.mailer.Send(message); err != nil {
+		return fmt.Errorf("Failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *emailVerificationService) VerifyEmail(req *dto.VerifyEmailRequest) error {
+	invalid := errors.NewValidationError("Invalid or expired verification token")
+
+	claims, err := s.jwtManager.ValidateToken(req.Token)
+	if err != nil || claims.Type != "email_verification" || claims.ID == "" {
+		return invalid
+	}
+
+	revoked, err := s.revokedTokenRepo.IsRevoked(claims.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return invalid
+	}
+
+	user, err := s.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return invalid
+		}
+		return fmt.Errorf("Failed to get user: %w", err)
+	}
+
+	// Tokens are bound to the address they were sent to.
+	if user.Email != claims.Email {
+		return invalid
+	}
+
+	if err := s.revokedTokenRepo.Revoke(&entities.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    user.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}); err != nil {
+		return fmt.Errorf("Failed to consume verification token: %w", err)
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("Failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerification succeeds silently for unknown or already verified
+// addresses so the endpoint cannot be used to discover accounts.
+func (s *emailVerificationService) ResendVerification(req *dto.ResendVerificationRequest) error {
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("Failed to get user: %w", err)
+	}
+
+	if user.EmailVerified || !user.IsActive {
+		return nil
+	}
+
+	// A failure is only logged: reporting it would tell the caller that the
+	// address is registered and unverified.
+	if err := s.SendVerification(user); err != nil {
+		log.Printf("Failed to resend verification to user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// tokenLink appends token to baseURL as the "token" query parameter.
+func tokenLink(baseURL, token string) (string, error) {
+	link, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("Invalid link URL: %w", err)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
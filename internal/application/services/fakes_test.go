@@ -0,0 +1,53 @@
+package services
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+// The fakes embed the repository interfaces and implement only what the
+// tests use; anything else panics.
+
+type fakeUserRepository struct {
+	repositories.UserRepository
+	users    map[uint]*entities.User
+	mfaSteps map[uint]int64
+}
+
+func (r *fakeUserRepository) GetByID(id uint) (*entities.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) Update(user *entities.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+// UseMFAStep keeps its own record of the last used steps, like the column
+// that Update never writes.
+func (r *fakeUserRepository) UseMFAStep(id uint, step int64) (bool, error) {
+	if step <= r.mfaSteps[id] {
+		return false, nil
+	}
+	if r.mfaSteps == nil {
+		r.mfaSteps = make(map[uint]int64)
+	}
+	r.mfaSteps[id] = step
+	return true, nil
+}
+
+type fakeSecurityEventRepository struct {
+	repositories.SecurityEventRepository
+	events []*entities.SecurityEvent
+}
+
+func (r *fakeSecurityEventRepository) Create(event *entities.SecurityEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
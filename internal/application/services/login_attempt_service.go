@@ -0,0 +1,165 @@
+package services
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LockoutPolicy configures how failed logins lock accounts and block IPs.
+// Reaching a threshold locks for BaseDuration; every further failure
+// doubles the lock, up to MaxDuration. Counters restart after ResetAfter
+// without failures, or on a successful login for accounts.
+type LockoutPolicy struct {
+	AccountThreshold int
+	IPThreshold      int
+	BaseDuration     time.Duration
+	MaxDuration      time.Duration
+	ResetAfter       time.Duration
+}
+
+func (p LockoutPolicy) lockDuration(attempts, threshold int) time.Duration {
+	if threshold <= 0 || attempts < threshold {
+		return 0
+	}
+
+	duration := float64(p.BaseDuration) * math.Pow(2, float64(attempts-threshold))
+	if duration > float64(p.MaxDuration) {
+		return p.MaxDuration
+	}
+	return time.Duration(duration)
+}
+
+type loginAttemptService struct {
+	userRepo          repositories.UserRepository
+	ipFailureRepo     repositories.IPLoginFailureRepository
+	securityEventRepo repositories.SecurityEventRepository
+	policy            LockoutPolicy
+}
+
+func NewLoginAttemptService(
+	userRepo repositories.UserRepository,
+	ipFailureRepo repositories.IPLoginFailureRepository,
+	securityEventRepo repositories.SecurityEventRepository,
+	policy LockoutPolicy,
+) services.LoginAttemptService {
+	return &loginAttemptService{
+		userRepo:          userRepo,
+		ipFailureRepo:     ipFailureRepo,
+		securityEventRepo: securityEventRepo,
+		policy:            policy,
+	}
+}
+
+func (s *loginAttemptService) CheckIP(ipAddress string) error {
+	if s.policy.IPThreshold <= 0 || ipAddress == "" {
+		return nil
+	}
+
+	failure, err := s.ipFailureRepo.Get(ipAddress)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("Failed to get login failures: %w", err)
+	}
+
+	if failure.BlockedUntil != nil {
+		if remaining := time.Until(*failure.BlockedUntil); remaining > 0 {
+			return errors.NewTooManyRequestsError(
+				fmt.Sprintf("Too many failed login attempts. Try again in %s", formatRetry(remaining)),
+				remaining,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (s *loginAttemptService) CheckUser(user *entities.User) error {
+	if user.LockedUntil != nil {
+		if remaining := time.Until(*user.LockedUntil); remaining > 0 {
+			return lockedError(remaining)
+		}
+	}
+	return nil
+}
+
+func (s *loginAttemptService) RecordFailure(user *entities.User, ipAddress string) error {
+	now := time.Now()
+	resetBefore := now.Add(-s.policy.ResetAfter)
+
+	if s.policy.IPThreshold > 0 && ipAddress != "" {
+		attempts, err := s.ipFailureRepo.RecordFailure(ipAddress, now, resetBefore)
+		if err != nil {
+			return fmt.Errorf("Failed to record login failure: %w", err)
+		}
+
+		if duration := s.policy.lockDuration(attempts, s.policy.IPThreshold); duration > 0 {
+			if err := s.ipFailureRepo.Block(ipAddress, now.Add(duration)); err != nil {
+				return fmt.Errorf("Failed to block IP address: %w", err)
+			}
+		}
+	}
+
+	if user == nil || s.policy.AccountThreshold <= 0 {
+		return nil
+	}
+
+	attempts, err := s.userRepo.RecordLoginFailure(user.ID, now, resetBefore)
+	if err != nil {
+		return fmt.Errorf("Failed to record login failure: %w", err)
+	}
+
+	duration := s.policy.lockDuration(attempts, s.policy.AccountThreshold)
+	if duration == 0 {
+		return nil
+	}
+
+	if err := s.userRepo.Lock(user.ID, now.Add(duration)); err != nil {
+		return fmt.Errorf("Failed to lock account: %w", err)
+	}
+
+	if err := s.securityEventRepo.Create(&entities.SecurityEvent{
+		UserID:  user.ID,
+		Type:    entities.SecurityEventAccountLocked,
+		Details: fmt.Sprintf("Locked for %s after %d failed login attempts from %s", duration, attempts, ipAddress),
+	}); err != nil {
+		return fmt.Errorf("Failed to record security event: %w", err)
+	}
+
+	return lockedError(duration)
+}
+
+func (s *loginAttemptService) RecordSuccess(user *entities.User) error {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return nil
+	}
+
+	if err := s.userRepo.ResetLoginFailures(user.ID); err != nil {
+		return fmt.Errorf("Failed to reset login failures: %w", err)
+	}
+	return nil
+}
+
+func lockedError(remaining time.Duration) error {
+	return errors.NewLockedError(
+		fmt.Sprintf("Account is temporarily locked. Try again in %s", formatRetry(remaining)),
+		remaining,
+	)
+}
+
+// formatRetry rounds up to whole minutes, e.g. "1 minute" or "15 minutes".
+func formatRetry(remaining time.Duration) string {
+	minutes := int(math.Ceil(remaining.Minutes()))
+	if minutes <= 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockDuration(t *testing.T) {
+	policy := LockoutPolicy{
+		BaseDuration: time.Minute,
+		MaxDuration:  time.Hour,
+	}
+
+	tests := []struct {
+		name      string
+		attempts  int
+		threshold int
+		want      time.Duration
+	}{
+		{"no failures", 0, 5, 0},
+		{"below threshold", 4, 5, 0},
+		{"at threshold", 5, 5, time.Minute},
+		{"one past threshold doubles", 6, 5, 2 * time.Minute},
+		{"two past threshold doubles twice", 7, 5, 4 * time.Minute},
+		{"last step below the cap", 10, 5, 32 * time.Minute},
+		{"capped", 11, 5, time.Hour},
+		{"far past threshold stays capped", 500, 5, time.Hour},
+		{"threshold of one", 1, 1, time.Minute},
+		{"disabled", 100, 0, 0},
+		{"negative threshold disables", 100, -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.lockDuration(tt.attempts, tt.threshold); got != tt.want {
+				t.Errorf("lockDuration(%d, %d) = %s, want %s", tt.attempts, tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
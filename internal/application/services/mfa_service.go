@@ -0,0 +1,278 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/domain/services"
+	"auth-system/internal/infrastructure/security"
+	"auth-system/pkg/errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const recoveryCodeCount = 10
+
+type mfaService struct {
+	userRepo          repositories.UserRepository
+	recoveryCodeRepo  repositories.RecoveryCodeRepository
+	securityEventRepo repositories.SecurityEventRepository
+	encrypter         *security.Encrypter
+	totpManager       *security.TOTPManager
+	passwordManager   *security.PasswordManager
+}
+
+func NewMFAService(
+	userRepo repositories.UserRepository,
+	recoveryCodeRepo repositories.RecoveryCodeRepository,
+	securityEventRepo repositories.SecurityEventRepository,
+	encrypter *security.Encrypter,
+	totpManager *security.TOTPManager,
+	passwordManager *security.PasswordManager,
+) services.MFAService {
+	return &mfaService{
+		userRepo:          userRepo,
+		recoveryCodeRepo:  recoveryCodeRepo,
+		securityEventRepo: securityEventRepo,
+		encrypter:         encrypter,
+		totpManager:       totpManager,
+		passwordManager:   passwordManager,
+	}
+}
+
+func (s *mfaService) BeginEnrollment(userID uint, req *dto.MFAEnrollRequest) (*dto.MFAEnrollmentResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replacing an active factor requires proof of possession of it.
+	if user.MFAEnabled {
+		if req.Code == "" {
+			return nil, errors.NewValidationError("Current MFA code is required to re-enroll")
+		}
+		if err := s.verifyTOTP(user, user.MFASecret, req.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	secret, err := s.totpManager.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate MFA secret: %w", err)
+	}
+
+	encrypted, err := s.encrypter.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encrypt MFA secret: %w", err)
+	}
+
+	user.MFAPendingSecret = encrypted
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("Failed to update user: %w", err)
+	}
+
+	return &dto.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: s.totpManager.ProvisioningURI(user.Email, secret),
+	}, nil
+}
+
+func (s *mfaService) ConfirmEnrollment(userID uint, req *dto.MFACodeRequest) (*dto.RecoveryCodesResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAPendingSecret == "" {
+		return nil, errors.NewValidationError("No MFA enrollment in progress")
+	}
+
+	// The last used step carries over from a previous secret: steps only
+	// grow with time, so it blocks at most the code of that same step.
+	if err := s.verifyTOTP(user, user.MFAPendingSecret, req.Code); err != nil {
+		return nil, err
+	}
+
+	user.MFASecret = user.MFAPendingSecret
+	user.MFAPendingSecret = ""
+	user.MFAEnabled = true
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("Failed to update user: %w", err)
+	}
+
+	if err := s.recordEvent(user.ID, entities.SecurityEventMFAEnabled, "TOTP authenticator enrolled"); err != nil {
+		return nil, err
+	}
+
+	return s.generateRecoveryCodes(user.ID)
+}
+
+func (s *mfaService) Disable(userID uint, req *dto.MFACodeRequest) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.MFAEnabled {
+		return errors.NewValidationError("MFA is not enabled")
+	}
+
+	if err := s.verifyTOTP(user, user.MFASecret, req.Code); err != nil {
+		return err
+	}
+
+	user.MFAEnabled = false
+	user.MFASecret = ""
+	user.MFAPendingSecret = ""
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("Failed to update user: %w", err)
+	}
+
+	if err := s.recoveryCodeRepo.DeleteByUserID(user.ID); err != nil {
+		return fmt.Errorf("Failed to delete recovery codes: %w", err)
+	}
+
+	return s.recordEvent(user.ID, entities.SecurityEventMFADisabled, "TOTP authenticator removed")
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(userID uint, req *dto.MFACodeRequest) (*dto.RecoveryCodesResponse, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.MFAEnabled {
+		return nil, errors.NewValidationError("MFA is not enabled")
+	}
+
+	if err := s.verifyTOTP(user, user.MFASecret, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recordEvent(user.ID, entities.SecurityEventRecoveryCodesNew, "Recovery codes regenerated"); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *mfaService) VerifySecondFactor(user *entities.User, code, recoveryCode string) error {
+	if !user.MFAEnabled {
+		return errors.NewValidationError("MFA is not enabled")
+	}
+
+	if recoveryCode != "" {
+		return s.useRecoveryCode(user, recoveryCode)
+	}
+
+	return s.verifyTOTP(user, user.MFASecret, code)
+}
+
+func (s *mfaService) getUser(userID uint) (*entities.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("User not found")
+		}
+		return nil, fmt.Errorf("Failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// verifyTOTP checks code against the encrypted secret and records the used
+// time step on the user so the same code cannot be replayed.
+func (s *mfaService) verifyTOTP(user *entities.User, encryptedSecret, code string) error {
+	secret, err := s.encrypter.Decrypt(encryptedSecret)
+	if err != nil {
+		return fmt.Errorf("Failed to decrypt MFA secret: %w", err)
+	}
+
+	step, ok := s.totpManager.Validate(string(secret), code, time.Now())
+	if !ok {
+		return errors.NewValidationError("Invalid MFA code")
+	}
+
+	// Checked against the stored step, not the loaded user, so concurrent
+	// requests cannot both use the same code.
+	used, err := s.userRepo.UseMFAStep(user.ID, step)
+	if err != nil {
+		return fmt.Errorf("Failed to update user: %w", err)
+	}
+	if !used {
+		return errors.NewValidationError("Invalid MFA code")
+	}
+	user.MFALastUsedStep = step
+
+	return nil
+}
+
+func (s *mfaService) useRecoveryCode(user *entities.User, code string) error {
+	codes, err := s.recoveryCodeRepo.ListUnusedByUserID(user.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to get recovery codes: %w", err)
+	}
+
+	normalized := security.NormalizeRecoveryCode(code)
+	for _, stored := range codes {
+		if s.passwordManager.CheckPassword(stored.CodeHash, normalized) != nil {
+			continue
+		}
+
+		used, err := s.recoveryCodeRepo.MarkUsed(stored.ID)
+		if err != nil {
+			return fmt.Errorf("Failed to use recovery code: %w", err)
+		}
+		if !used {
+			break
+		}
+
+		return s.recordEvent(user.ID, entities.SecurityEventRecoveryCodeUsed,
+			fmt.Sprintf("Recovery code used, %d remaining", len(codes)-1))
+	}
+
+	return errors.NewValidationError("Invalid recovery code")
+}
+
+func (s *mfaService) generateRecoveryCodes(userID uint) (*dto.RecoveryCodesResponse, error) {
+	plain := make([]string, recoveryCodeCount)
+	records := make([]*entities.RecoveryCode, recoveryCodeCount)
+
+	for i := range plain {
+		code, err := security.GenerateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to generate recovery code: %w", err)
+		}
+
+		hash, err := s.passwordManager.HashPassword(security.NormalizeRecoveryCode(code))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to hash recovery code: %w", err)
+		}
+
+		plain[i] = code
+		records[i] = &entities.RecoveryCode{CodeHash: hash}
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, records); err != nil {
+		return nil, fmt.Errorf("Failed to store recovery codes: %w", err)
+	}
+
+	return &dto.RecoveryCodesResponse{RecoveryCodes: plain}, nil
+}
+
+func (s *mfaService) recordEvent(userID uint, eventType, details string) error {
+	if err := s.securityEventRepo.Create(&entities.SecurityEvent{
+		UserID:  userID,
+		Type:    eventType,
+		Details: details,
+	}); err != nil {
+		return fmt.Errorf("Failed to record security event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/infrastructure/security"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRecoveryCodeRepository struct {
+	repositories.RecoveryCodeRepository
+	codes []*entities.RecoveryCode
+}
+
+func (r *fakeRecoveryCodeRepository) ReplaceForUser(userID uint, codes []*entities.RecoveryCode) error {
+	r.codes = nil
+	for i, code := range codes {
+		code.ID = uint(i + 1)
+		code.UserID = userID
+		r.codes = append(r.codes, code)
+	}
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepository) ListUnusedByUserID(userID uint) ([]*entities.RecoveryCode, error) {
+	var codes []*entities.RecoveryCode
+	for _, code := range r.codes {
+		if code.UserID == userID && code.UsedAt == nil {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+func (r *fakeRecoveryCodeRepository) MarkUsed(id uint) (bool, error) {
+	for _, code := range r.codes {
+		if code.ID == id && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type mfaTest struct {
+	service     *mfaService
+	totpManager *security.TOTPManager
+	events      *fakeSecurityEventRepository
+	user        *entities.User
+	secret      string
+}
+
+// newMFATest returns a service where user 1 has enabled TOTP.
+func newMFATest(t *testing.T) *mfaTest {
+	t.Helper()
+
+	encrypter, err := security.NewEncrypter("test-encryption-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mfaTest{
+		totpManager: security.NewTOTPManager("Example"),
+		events:      &fakeSecurityEventRepository{},
+	}
+	m.secret, err = m.totpManager.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := encrypter.Encrypt([]byte(m.secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.user = &entities.User{ID: 1, Email: "user@example.com", MFAEnabled: true, MFASecret: encrypted}
+	m.service = NewMFAService(
+		&fakeUserRepository{users: map[uint]*entities.User{1: m.user}},
+		&fakeRecoveryCodeRepository{},
+		m.events,
+		encrypter,
+		m.totpManager,
+		security.NewPasswordManager(),
+	).(*mfaService)
+	return m
+}
+
+func (m *mfaTest) code(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := m.totpManager.GenerateCode(m.secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestMFAServiceRejectsReplayedTOTP(t *testing.T) {
+	m := newMFATest(t)
+	now := time.Now()
+
+	code := m.code(t, now)
+	if err := m.service.VerifySecondFactor(m.user, code, ""); err != nil {
+		t.Fatalf("VerifySecondFactor error = %v", err)
+	}
+	if m.user.MFALastUsedStep == 0 {
+		t.Fatal("the used time step was not recorded")
+	}
+
+	if err := m.service.VerifySecondFactor(m.user, code, ""); err == nil {
+		t.Error("replayed code was accepted")
+	}
+	// A code of an earlier step, still inside the window, is no better.
+	if err := m.service.VerifySecondFactor(m.user, m.code(t, now.Add(-30*time.Second)), ""); err == nil {
+		t.Error("code of an earlier step was accepted")
+	}
+	// The next step's code is.
+	if err := m.service.VerifySecondFactor(m.user, m.code(t, now.Add(30*time.Second)), ""); err != nil {
+		t.Errorf("code of the next step was rejected: %v", err)
+	}
+}
+
+// A request that loaded the user before the code was used, as a concurrent
+// one would, cannot use it again.
+func TestMFAServiceRejectsTOTPReplayedWithStaleUser(t *testing.T) {
+	m := newMFATest(t)
+	stale := *m.user
+
+	code := m.code(t, time.Now())
+	if err := m.service.VerifySecondFactor(m.user, code, ""); err != nil {
+		t.Fatalf("VerifySecondFactor error = %v", err)
+	}
+	if err := m.service.VerifySecondFactor(&stale, code, ""); err == nil {
+		t.Error("code replayed with a user loaded before its use was accepted")
+	}
+}
+
+func TestMFAServiceRecoveryCodesAreSingleUse(t *testing.T) {
+	m := newMFATest(t)
+
+	codes, err := m.service.generateRecoveryCodes(m.user.ID)
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes error = %v", err)
+	}
+	if len(codes.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("generated %d recovery codes, want %d", len(codes.RecoveryCodes), recoveryCodeCount)
+	}
+
+	first := codes.RecoveryCodes[0]
+	// Codes are accepted however they are typed.
+	if err := m.service.VerifySecondFactor(m.user, "", strings.ToUpper(strings.ReplaceAll(first, "-", " "))); err != nil {
+		t.Fatalf("VerifySecondFactor error = %v", err)
+	}
+	if err := m.service.VerifySecondFactor(m.user, "", first); err == nil {
+		t.Error("used recovery code was accepted again")
+	}
+
+	if err := m.service.VerifySecondFactor(m.user, "", codes.RecoveryCodes[1]); err != nil {
+		t.Errorf("unused recovery code was rejected: %v", err)
+	}
+	if err := m.service.VerifySecondFactor(m.user, "", "aaaaa-aaaaa"); err == nil {
+		t.Error("unknown recovery code was accepted")
+	}
+
+	var used []string
+	for _, event := range m.events.events {
+		if event.Type == entities.SecurityEventRecoveryCodeUsed {
+			used = append(used, event.Details)
+		}
+	}
+	want := []string{"Recovery code used, 9 remaining", "Recovery code used, 8 remaining"}
+	if strings.Join(used, "; ") != strings.Join(want, "; ") {
+		t.Errorf("recorded %q, want %q", used, want)
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/domain/services"
+	"auth-system/internal/infrastructure/mail"
+	"auth-system/internal/infrastructure/security"
+	"auth-system/pkg/errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type passwordResetService struct {
+	userRepo          repositories.UserRepository
+	resetTokenRepo    repositories.PasswordResetTokenRepository
+	securityEventRepo repositories.SecurityEventRepository
+	sessionService    services.SessionService
+	passwordManager   *security.PasswordManager
+	mailer            mail.Mailer
+	templates         *mail.Templates
+	tokenTTL          time.Duration
+	resetURL          string
+	inviteTTL         time.Duration
+	inviteURL         string
+}
+
+func NewPasswordResetService(
+	userRepo repositories.UserRepository,
+	resetTokenRepo repositories.PasswordResetTokenRepository,
+	securityEventRepo repositories.SecurityEventRepository,
+	sessionService services.SessionService,
+	passwordManager *security.PasswordManager,
+	mailer mail.Mailer,
+	templates *mail.Templates,
+	tokenTTL time.Duration,
+	resetURL string,
+	inviteTTL time.Duration,
+	inviteURL string,
+) services.PasswordResetService {
+	return &passwordResetService{
+		userRepo:          userRepo,
+		resetTokenRepo:    resetTokenRepo,
+		securityEventRepo: securityEventRepo,
+		sessionService:    sessionService,
+		passwordManager:   passwordManager,
+		mailer:            mailer,
+		templates:         templates,
+		tokenTTL:          tokenTTL,
+		resetURL:          resetURL,
+		inviteTTL:         inviteTTL,
+		inviteURL:         inviteURL,
+	}
+}
+
+func (s *passwordResetService) ForgotPassword(req *dto.ForgotPasswordRequest) error {
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("Failed to get user: %w", err)
+	}
+
+	if !user.IsActive {
+		return nil
+	}
+
+	// A failure is only logged: reporting it would tell the caller that the
+	// address is registered.
+	if err := s.sendToken(user, "reset_password", s.tokenTTL, s.resetURL); err != nil {
+		log.Printf("Failed to send password reset to user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+func (s *passwordResetService) SendInvite(user *entities.User) error {
+	return s.sendToken(user, "invite", s.inviteTTL, s.inviteURL)
+}
+
+// sendToken stores a new reset token for user and mails a link to baseURL
+// with it using the named template.
+func (s *passwordResetService) sendToken(user *entities.User, template string, ttl time.Duration, baseURL string) error {
+	token, err := security.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("Failed to generate reset token: %w", err)
+	}
+
+	if err := s.resetTokenRepo.Create(&entities.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: security.HashOpaqueToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return fmt.Errorf("Failed to store reset token: %w", err)
+	}
+
+	link, err := tokenLink(baseURL, token)
+	if err != nil {
+		return err
+	}
+
+	message, err := s.templates.Render(template, user.Locale, user.Email, map[string]any{
+		"Name":      user.FirstName,
+		"Link":      link,
+		"ExpiresIn": ttl,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to render %s email: %w", template, err)
+	}
+
+	if err := s.mailer.Send(message); err != nil {
+		return fmt.Errorf("Failed to send %s email: %w", template, err)
+	}
+
+	return nil
+}
+
+func (s *passwordResetService) ResetPassword(req *dto.ResetPasswordRequest) error {
+	invalid := errors.NewValidationError("Invalid or expired reset token")
+
+	token, err := s.resetTokenRepo.GetByTokenHash(security.HashOpaqueToken(req.Token))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return invalid
+		}
+		return fmt.Errorf("Failed to get reset token: %w", err)
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return invalid
+	}
+
+	used, err := s.resetTokenRepo.MarkUsed(token.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to use reset token: %w", err)
+	}
+	if !used {
+		return invalid
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return invalid
+		}
+		return fmt.Errorf("Failed to get user: %w", err)
+	}
+
+	if !user.IsActive {
+		return errors.NewValidationError("Account is deactivated")
+	}
+
+	hashedPassword, err := s.passwordManager.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("Failed to hash password: %w", err)
+	}
+
+	user.Password = hashedPassword
+	// The token was delivered to the address, which proves the user owns it.
+	if !user.EmailVerified {
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("Failed to update password: %w", err)
+	}
+
+	// Whoever knew the old password may still be signed in.
+	if err := s.sessionService.RevokeAllSessions(user.ID); err != nil {
+		return err
+	}
+
+	if err := s.resetTokenRepo.DeleteByUserID(user.ID); err != nil {
+		return fmt.Errorf("Failed to delete reset tokens: %w", err)
+	}
+
+	if err := s.securityEventRepo.Create(&entities.SecurityEvent{
+		UserID:  user.ID,
+		Type:    entities.SecurityEventPasswordReset,
+		Details: "Password reset through email link, all sessions revoked",
+	}); err != nil {
+		return fmt.Errorf("Failed to record security event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"fmt"
+	"strings"
+)
+
+// Permission patterns. A resource is a path of segments separated by "/";
+// in a granted permission a segment may be "*", matching exactly one
+// segment, and the last segment may be "**", matching one or more. The
+// action may be "*", matching any action. So "users.*" grants every action
+// on users, "*.read" reads every single-segment resource,
+// "projects/*/documents.read" reads the documents of any project and
+// "billing/**.read" reads anything under billing.
+const (
+	wildcardSegment = "*"
+	wildcardRest    = "**"
+	wildcardAction  = "*"
+)
+
+// permissionPolicy decides a user's permission checks. Grants and denials
+// are compiled into separate matchers once, when the user's grants are
+// loaded, and the policy is cached until the RBAC version changes.
+type permissionPolicy struct {
+	allow permissionMatcher
+	deny  permissionMatcher
+}
+
+func newPermissionPolicy(grants []*entities.PermissionGrant) *permissionPolicy {
+	policy := &permissionPolicy{}
+	for _, grant := range grants {
+		if grant.Effect == entities.PermissionEffectDeny {
+			policy.deny.add(grant)
+		} else {
+			policy.allow.add(grant)
+		}
+	}
+	return policy
+}
+
+// decide refuses if any denial matches, however specific the grants, and
+// otherwise allows if a grant matches. The decision names the most
+// specific matching denial or grant.
+func (p *permissionPolicy) decide(resource, action string) *dto.PermissionDecision {
+	decision := &dto.PermissionDecision{Resource: resource, Action: action}
+
+	grant := p.deny.match(resource, action)
+	if grant == nil {
+		grant = p.allow.match(resource, action)
+	}
+	if grant == nil {
+		decision.Reason = fmt.Sprintf("No role grants %s.%s", resource, action)
+		return decision
+	}
+
+	decision.Allowed = grant.Effect != entities.PermissionEffectDeny
+	decision.Permission = grant.Permission.Name
+	decision.Effect = grant.Effect
+	decision.Role = grant.Role
+	switch {
+	case decision.Allowed:
+		decision.Reason = fmt.Sprintf("Granted by %s through role %s", grant.Permission.Name, grant.Role)
+	case grant.Role != "":
+		decision.Reason = fmt.Sprintf("Denied by %s through role %s", grant.Permission.Name, grant.Role)
+	default:
+		decision.Reason = fmt.Sprintf("Denied by %s, denied to the user directly", grant.Permission.Name)
+	}
+	return decision
+}
+
+// permissionMatcher is a set of grants compiled into a trie over the
+// resource segments, so a check walks the requested resource once instead
+// of comparing it with every permission.
+type permissionMatcher struct {
+	root permissionNode
+}
+
+type permissionNode struct {
+	children map[string]*permissionNode
+	wildcard *permissionNode
+	// rest holds the grants whose resource ends in "**" here.
+	rest map[string]*entities.PermissionGrant
+	// actions holds the grants whose resource ends here, by action.
+	actions map[string]*entities.PermissionGrant
+}
+
+func (m *permissionMatcher) add(grant *entities.PermissionGrant) {
+	node := &m.root
+	segments := strings.Split(grant.Permission.Resource, "/")
+	for i, segment := range segments {
+		if segment == wildcardRest && i == len(segments)-1 {
+			node.rest = addAction(node.rest, grant)
+			return
+		}
+		node = node.child(segment)
+	}
+	node.actions = addAction(node.actions, grant)
+}
+
+func (n *permissionNode) child(segment string) *permissionNode {
+	if segment == wildcardSegment {
+		if n.wildcard == nil {
+			n.wildcard = &permissionNode{}
+		}
+		return n.wildcard
+	}
+
+	if n.children == nil {
+		n.children = make(map[string]*permissionNode)
+	}
+	child, ok := n.children[segment]
+	if !ok {
+		child = &permissionNode{}
+		n.children[segment] = child
+	}
+	return child
+}
+
+// addAction keeps one grant per action. Several roles may grant the same
+// permission; which of them is named in decisions does not matter.
+func addAction(actions map[string]*entities.PermissionGrant, grant *entities.PermissionGrant) map[string]*entities.PermissionGrant {
+	if actions == nil {
+		actions = make(map[string]*entities.PermissionGrant)
+	}
+	if _, ok := actions[grant.Permission.Action]; !ok {
+		actions[grant.Permission.Action] = grant
+	}
+	return actions
+}
+
+// match returns the most specific grant of action on resource, or nil.
+// Resources are compared segment by segment from the left, where a literal
+// segment beats "*" and "*" beats "**"; only between equally specific
+// resources does an exact action beat "*". So for users.read, "users.*"
+// takes precedence over "*.read".
+func (m *permissionMatcher) match(resource, action string) *entities.PermissionGrant {
+	return m.root.match(strings.Split(resource, "/"), action)
+}
+
+func (n *permissionNode) match(segments []string, action string) *entities.PermissionGrant {
+	if len(segments) == 0 {
+		return matchAction(n.actions, action)
+	}
+
+	if child := n.children[segments[0]]; child != nil {
+		if grant := child.match(segments[1:], action); grant != nil {
+			return grant
+		}
+	}
+	if n.wildcard != nil {
+		if grant := n.wildcard.match(segments[1:], action); grant != nil {
+			return grant
+		}
+	}
+	return matchAction(n.rest, action)
+}
+
+func matchAction(actions map[string]*entities.PermissionGrant, action string) *entities.PermissionGrant {
+	if grant, ok := actions[action]; ok {
+		return grant
+	}
+	return actions[wildcardAction]
+}
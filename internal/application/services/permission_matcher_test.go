@@ -0,0 +1,220 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"strings"
+	"testing"
+)
+
+// testGrant builds a grant from "<resource>.<action>"; an empty role makes
+// a denial to the user directly.
+func testGrant(effect, name, role string) *entities.PermissionGrant {
+	dot := strings.LastIndex(name, ".")
+	return &entities.PermissionGrant{
+		Permission: entities.Permission{Name: name, Resource: name[:dot], Action: name[dot+1:]},
+		Effect:     effect,
+		Role:       role,
+	}
+}
+
+func allow(name, role string) *entities.PermissionGrant {
+	return testGrant(entities.PermissionEffectAllow, name, role)
+}
+
+func deny(name, role string) *entities.PermissionGrant {
+	return testGrant(entities.PermissionEffectDeny, name, role)
+}
+
+func TestPermissionPolicyDecide(t *testing.T) {
+	tests := []struct {
+		name     string
+		grants   []*entities.PermissionGrant
+		resource string
+		action   string
+		want     dto.PermissionDecision
+	}{
+		{
+			name:     "nothing granted",
+			resource: "users", action: "read",
+			want: dto.PermissionDecision{Reason: "No role grants users.read"},
+		},
+		{
+			name:     "exact grant",
+			grants:   []*entities.PermissionGrant{allow("users.read", "viewer")},
+			resource: "users", action: "read",
+			want: dto.PermissionDecision{Allowed: true, Permission: "users.read", Effect: "allow", Role: "viewer", Reason: "Granted by users.read through role viewer"},
+		},
+		{
+			name:     "other action",
+			grants:   []*entities.PermissionGrant{allow("users.read", "viewer")},
+			resource: "users", action: "write",
+			want: dto.PermissionDecision{Reason: "No role grants users.write"},
+		},
+		{
+			name:     "literal beats *",
+			grants:   []*entities.PermissionGrant{allow("users/*.read", "any"), allow("users/42.read", "owner")},
+			resource: "users/42", action: "read",
+			want: dto.PermissionDecision{Allowed: true, Permission: "users/42.read", Effect: "allow", Role: "owner", Reason: "Granted by users/42.read through role owner"},
+		},
+		{
+			name:     "* beats **",
+			grants:   []*entities.PermissionGrant{allow("users/**.read", "deep"), allow("users/*.read", "shallow")},
+			resource: "users/42", action: "read",
+			want: dto.PermissionDecision{Allowed: true, Permission: "users/*.read", Effect: "allow", Role: "shallow", Reason: "Granted by users/*.read through role shallow"},
+		},
+		{
+			name:     "users.* beats *.read",
+			grants:   []*entities.PermissionGrant{allow("*.read", "reader"), allow("users.*", "admin")},
+			resource: "users", action: "read",
+			want: dto.PermissionDecision{Allowed: true, Permission: "users.*", Effect: "allow", Role: "admin", Reason: "Granted by users.* through role admin"},
+		},
+		{
+			name:     "exact action beats * on the same resource",
+			grants:   []*entities.PermissionGrant{allow("users.*", "admin"), allow("users.read", "viewer")},
+			resource: "users", action: "read",
+			want: dto.PermissionDecision{Allowed: true, Permission: "users.read", Effect: "allow", Role: "viewer", Reason: "Granted by users.read through role viewer"},
+		},
+		{
+			name:     "a literal that leads nowhere falls back to *",
+			grants:   []*entities.PermissionGrant{allow("projects/42.read", "owner"), allow("projects/*/documents.read", "editor")},
+			resource: "projects/42/documents", action: "read",
+			want: dto.PermissionDecision{Allowed: true, Permission: "projects/*/documents.read", Effect: "allow", Role: "editor", Reason: "Granted by projects/*/documents.read through role editor"},
+		},
+		{
+			name:     "* matches one segment only",
+			grants:   []*entities.PermissionGrant{allow("users/*.read", "viewer")},
+			resource: "users/42/profile", action: "read",
+			want: dto.PermissionDecision{Reason: "No role grants users/42/profile.read"},
+		},
+		{
+			name:     "** needs at least one segment",
+			grants:   []*entities.PermissionGrant{allow("billing/**.read", "billing")},
+			resource: "billing", action: "read",
+			want: dto.PermissionDecision{Reason: "No role grants billing.read"},
+		},
+		{
+			name:     "** matches several segments",
+			grants:   []*entities.PermissionGrant{allow("billing/**.read", "billing")},
+			resource: "billing/invoices/2024/7", action: "read",
+			want: dto.PermissionDecision{Allowed: true, Permission: "billing/**.read", Effect: "allow", Role: "billing", Reason: "Granted by billing/**.read through role billing"},
+		},
+		{
+			name:     "**.* grants everything",
+			grants:   []*entities.PermissionGrant{allow("**.*", "root")},
+			resource: "anything/at/all", action: "delete",
+			want: dto.PermissionDecision{Allowed: true, Permission: "**.*", Effect: "allow", Role: "root", Reason: "Granted by **.* through role root"},
+		},
+		{
+			name:     "the first role granting a permission is named",
+			grants:   []*entities.PermissionGrant{allow("users.read", "first"), allow("users.read", "second")},
+			resource: "users", action: "read",
+			want: dto.PermissionDecision{Allowed: true, Permission: "users.read", Effect: "allow", Role: "first", Reason: "Granted by users.read through role first"},
+		},
+		{
+			name:     "a denial beats a more specific grant",
+			grants:   []*entities.PermissionGrant{allow("users/42.read", "owner"), deny("users/**.read", "suspended")},
+			resource: "users/42", action: "read",
+			want: dto.PermissionDecision{Permission: "users/**.read", Effect: "deny", Role: "suspended", Reason: "Denied by users/**.read through role suspended"},
+		},
+		{
+			name:     "the most specific denial is named",
+			grants:   []*entities.PermissionGrant{allow("**.*", "root"), deny("users/**.read", "support"), deny("users/42.read", "")},
+			resource: "users/42", action: "read",
+			want: dto.PermissionDecision{Permission: "users/42.read", Effect: "deny", Reason: "Denied by users/42.read, denied to the user directly"},
+		},
+		{
+			name:     "a role denial can be more specific than a user denial",
+			grants:   []*entities.PermissionGrant{allow("**.*", "root"), deny("users/**.read", ""), deny("users/42.*", "support")},
+			resource: "users/42", action: "read",
+			want: dto.PermissionDecision{Permission: "users/42.*", Effect: "deny", Role: "support", Reason: "Denied by users/42.* through role support"},
+		},
+		{
+			name:     "a denial of another action does not apply",
+			grants:   []*entities.PermissionGrant{allow("users.*", "admin"), deny("users.delete", "")},
+			resource: "users", action: "read",
+			want: dto.PermissionDecision{Allowed: true, Permission: "users.*", Effect: "allow", Role: "admin", Reason: "Granted by users.* through role admin"},
+		},
+		{
+			name:     "a denial without a grant",
+			grants:   []*entities.PermissionGrant{deny("users.read", "")},
+			resource: "users", action: "read",
+			want: dto.PermissionDecision{Permission: "users.read", Effect: "deny", Reason: "Denied by users.read, denied to the user directly"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Resource = tt.resource
+			tt.want.Action = tt.action
+
+			got := newPermissionPolicy(tt.grants).decide(tt.resource, tt.action)
+			if *got != tt.want {
+				t.Errorf("decide(%s, %s) =\n  %+v\nwant\n  %+v", tt.resource, tt.action, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPermission(t *testing.T) {
+	tests := []struct {
+		resource string
+		action   string
+		want     string
+	}{
+		{"users", "read", "users.read"},
+		{" users ", " read ", "users.read"},
+		{"projects/42/documents", "read", "projects/42/documents.read"},
+		{"user_profiles/api-keys", "rotate_key", "user_profiles/api-keys.rotate_key"},
+		{"users", "*", "users.*"},
+		{"*", "read", "*.read"},
+		{"projects/*/documents", "read", "projects/*/documents.read"},
+		{"billing/**", "read", "billing/**.read"},
+		{"**", "*", "**.*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			permission, err := newPermission(&dto.PermissionRequest{Resource: tt.resource, Action: tt.action})
+			if err != nil {
+				t.Fatalf("newPermission(%q, %q) error = %v", tt.resource, tt.action, err)
+			}
+			if permission.Name != tt.want || permission.Name != permission.Resource+"."+permission.Action {
+				t.Errorf("newPermission(%q, %q) = %+v, want name %s", tt.resource, tt.action, permission, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPermissionErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		action   string
+		error    string
+	}{
+		{"** before the last segment", "billing/**/invoices", "read", "'**' is only allowed as the last segment"},
+		{"** alone before a segment", "**/users", "read", "'**' is only allowed as the last segment"},
+		{"empty resource", "", "read", "Invalid resource"},
+		{"empty segment", "users//42", "read", "Invalid resource"},
+		{"trailing slash", "users/", "read", "Invalid resource"},
+		{"uppercase segment", "Users", "read", "Invalid resource"},
+		{"dot in resource", "users.admin", "read", "Invalid resource"},
+		{"partial wildcard", "users/4*", "read", "Invalid resource"},
+		{"triple wildcard", "users/***", "read", "Invalid resource"},
+		{"empty action", "users", "", "Invalid action"},
+		{"uppercase action", "users", "Read", "Invalid action"},
+		{"action starting with a digit", "users", "1read", "Invalid action"},
+		{"** as action", "users", "**", "Invalid action"},
+		{"dot in action", "users", "re.ad", "Invalid action"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPermission(&dto.PermissionRequest{Resource: tt.resource, Action: tt.action})
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("newPermission(%q, %q) error = %v, want one containing %q", tt.resource, tt.action, err, tt.error)
+			}
+		})
+	}
+}
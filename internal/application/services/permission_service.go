@@ -1,15 +1,39 @@
 package services
 
 import (
+	"auth-system/internal/application/dto"
 	"auth-system/internal/domain/entities"
 	"auth-system/internal/domain/repositories"
 	"auth-system/internal/domain/services"
+	"auth-system/pkg/errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// permissionPartPattern restricts actions and resource segments to
+// lowercase identifiers so that "<resource>.<action>" names stay
+// unambiguous. Segments may start with a digit, e.g. "projects/42".
+var (
+	permissionPartPattern    = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	permissionSegmentPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
 )
 
+// maxCachedPolicies bounds the policy cache; it starts over once full.
+const maxCachedPolicies = 10000
+
 type permissionService struct {
 	permissionRepo repositories.PermissionRepository
 	userRepo       repositories.UserRepository
+
+	// policies caches the compiled policy of each user for the RBAC
+	// version it was loaded at.
+	mu       sync.Mutex
+	version  int64
+	policies map[uint]*permissionPolicy
 }
 
 func NewPermissionService(
@@ -23,20 +47,320 @@ func NewPermissionService(
 }
 
 func (s *permissionService) CheckPermission(userID uint, resource, action string) (bool, error) {
-	permissions, err := s.permissionRepo.GetByUserID(userID)
+	decision, err := s.Decide(userID, resource, action)
+	if err != nil {
+		return false, err
+	}
+	return decision.Allowed, nil
+}
+
+func (s *permissionService) Decide(userID uint, resource, action string) (*dto.PermissionDecision, error) {
+	policy, err := s.policy(userID)
+	if err != nil {
+		return nil, err
+	}
+	return policy.decide(resource, action), nil
+}
+
+// policy returns the user's compiled policy. Cached policies are dropped
+// as soon as the RBAC version moves, so changes to roles, grants and
+// denials apply to the next request on every instance.
+func (s *permissionService) policy(userID uint) (*permissionPolicy, error) {
+	// The version is read before the grants: a change committed in between
+	// leaves the policy cached under an older version, to be reloaded.
+	version, err := s.permissionRepo.Version()
 	if err != nil {
-		return false, fmt.Errorf("Failed to get user permissions: %w", err)
+		return nil, fmt.Errorf("Failed to get RBAC version: %w", err)
+	}
+
+	s.mu.Lock()
+	if version != s.version {
+		s.version = version
+		s.policies = nil
+	}
+	policy, ok := s.policies[userID]
+	s.mu.Unlock()
+	if ok {
+		return policy, nil
 	}
 
-	for _, perm := range permissions {
-		if perm.Resource == resource && perm.Action == action {
-			return true, nil
+	grants, err := s.permissionRepo.GetGrantsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get user permissions: %w", err)
+	}
+	policy = newPermissionPolicy(grants)
+
+	s.mu.Lock()
+	if version == s.version {
+		if s.policies == nil || len(s.policies) >= maxCachedPolicies {
+			s.policies = make(map[uint]*permissionPolicy)
 		}
+		s.policies[userID] = policy
 	}
+	s.mu.Unlock()
 
-	return false, nil
+	return policy, nil
 }
 
 func (s *permissionService) GetUserPermissions(userID uint) ([]*entities.Permission, error) {
 	return s.permissionRepo.GetByUserID(userID)
 }
+
+func (s *permissionService) ListPermissions() ([]dto.PermissionResponse, error) {
+	permissions, err := s.permissionRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list permissions: %w", err)
+	}
+
+	responses := make([]dto.PermissionResponse, len(permissions))
+	for i, permission := range permissions {
+		responses[i] = mapPermissionToResponse(permission)
+	}
+	return responses, nil
+}
+
+func (s *permissionService) GetPermission(permissionID uint) (*dto.PermissionResponse, error) {
+	permission, err := s.getPermission(permissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := mapPermissionToResponse(permission)
+	return &response, nil
+}
+
+func (s *permissionService) CreatePermission(req *dto.PermissionRequest) (*dto.PermissionResponse, error) {
+	permission, err := newPermission(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNameAvailable(permission.Name, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.permissionRepo.Create(permission); err != nil {
+		return nil, fmt.Errorf("Failed to create permission: %w", err)
+	}
+
+	response := mapPermissionToResponse(permission)
+	return &response, nil
+}
+
+func (s *permissionService) UpdatePermission(permissionID uint, req *dto.PermissionRequest) (*dto.PermissionResponse, error) {
+	permission, err := s.getPermission(permissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := newPermission(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNameAvailable(updated.Name, permission.ID); err != nil {
+		return nil, err
+	}
+
+	permission.Name = updated.Name
+	permission.Resource = updated.Resource
+	permission.Action = updated.Action
+	permission.Description = updated.Description
+	if err := s.permissionRepo.Update(permission); err != nil {
+		return nil, fmt.Errorf("Failed to update permission: %w", err)
+	}
+	response := mapPermissionToResponse(permission)
+	return &response, nil
+}
+
+func (s *permissionService) DeletePermission(permissionID uint, force bool) error {
+	permission, err := s.getPermission(permissionID)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		roles, err := s.permissionRepo.CountRoles(permission.ID)
+		if err != nil {
+			return fmt.Errorf("Failed to count permission roles: %w", err)
+		}
+		if roles > 0 {
+			return errors.NewConflictError(fmt.Sprintf("Permission is granted to or denied by %d role(s); use force to delete it anyway", roles))
+		}
+
+		// Deleting a denial silently widens access, so it needs force too.
+		users, err := s.permissionRepo.CountUserDenials(permission.ID)
+		if err != nil {
+			return fmt.Errorf("Failed to count permission denials: %w", err)
+		}
+		if users > 0 {
+			return errors.NewConflictError(fmt.Sprintf("Permission is denied to %d user(s); use force to delete it anyway", users))
+		}
+	}
+
+	if err := s.permissionRepo.Delete(permission.ID); err != nil {
+		return fmt.Errorf("Failed to delete permission: %w", err)
+	}
+
+	return nil
+}
+
+func (s *permissionService) RegisterPermissions(req *dto.RegisterPermissionsRequest) ([]dto.PermissionResponse, error) {
+	permissions := make([]*entities.Permission, 0, len(req.Permissions))
+	byName := make(map[string]*entities.Permission, len(req.Permissions))
+	for i := range req.Permissions {
+		permission, err := newPermission(&req.Permissions[i])
+		if err != nil {
+			return nil, err
+		}
+
+		// A batch may not touch the same row twice, the last entry wins.
+		if existing, ok := byName[permission.Name]; ok {
+			existing.Description = permission.Description
+			continue
+		}
+		byName[permission.Name] = permission
+		permissions = append(permissions, permission)
+	}
+
+	if err := s.permissionRepo.Upsert(permissions); err != nil {
+		return nil, fmt.Errorf("Failed to register permissions: %w", err)
+	}
+
+	responses := make([]dto.PermissionResponse, len(permissions))
+	for i, permission := range permissions {
+		responses[i] = mapPermissionToResponse(permission)
+	}
+	return responses, nil
+}
+
+func (s *permissionService) ListUserDenials(userID uint) ([]dto.PermissionResponse, error) {
+	if err := s.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.userRepo.GetDeniedPermissions(userID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list denied permissions: %w", err)
+	}
+
+	responses := make([]dto.PermissionResponse, len(permissions))
+	for i := range permissions {
+		responses[i] = mapPermissionToResponse(&permissions[i])
+	}
+	return responses, nil
+}
+
+func (s *permissionService) DenyUserPermissions(userID uint, req *dto.DeniedPermissionsRequest) ([]dto.PermissionResponse, error) {
+	if err := s.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	permissions := make([]entities.Permission, 0, len(req.PermissionIDs))
+	for _, id := range req.PermissionIDs {
+		permission, err := s.permissionRepo.GetByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, errors.NewValidationError(fmt.Sprintf("Permission %d not found", id))
+			}
+			return nil, fmt.Errorf("Failed to get permission: %w", err)
+		}
+		permissions = append(permissions, *permission)
+	}
+
+	if err := s.userRepo.AddDeniedPermissions(userID, permissions); err != nil {
+		return nil, fmt.Errorf("Failed to deny permissions: %w", err)
+	}
+
+	return s.ListUserDenials(userID)
+}
+
+func (s *permissionService) RemoveUserDenial(userID, permissionID uint) ([]dto.PermissionResponse, error) {
+	denied, err := s.ListUserDenials(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, permission := range denied {
+		if permission.ID == permissionID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.NewValidationError("User is not denied this permission")
+	}
+
+	if err := s.userRepo.RemoveDeniedPermission(userID, permissionID); err != nil {
+		return nil, fmt.Errorf("Failed to remove denial: %w", err)
+	}
+
+	return s.ListUserDenials(userID)
+}
+
+func (s *permissionService) checkUserExists(userID uint) error {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFoundError("User not found")
+		}
+		return fmt.Errorf("Failed to get user: %w", err)
+	}
+	return nil
+}
+
+func (s *permissionService) getPermission(permissionID uint) (*entities.Permission, error) {
+	permission, err := s.permissionRepo.GetByID(permissionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("Permission not found")
+		}
+		return nil, fmt.Errorf("Failed to get permission: %w", err)
+	}
+	return permission, nil
+}
+
+// checkNameAvailable fails if another permission than permissionID uses name.
+func (s *permissionService) checkNameAvailable(name string, permissionID uint) error {
+	existing, err := s.permissionRepo.GetByName(name)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("Failed to get permission: %w", err)
+	}
+	if existing.ID != permissionID {
+		return errors.NewConflictError(fmt.Sprintf("Permission %s already exists", name))
+	}
+	return nil
+}
+
+// newPermission validates the request and derives the permission name.
+// The resource and action may be patterns, see permission_matcher.go.
+func newPermission(req *dto.PermissionRequest) (*entities.Permission, error) {
+	resource := strings.TrimSpace(req.Resource)
+	action := strings.TrimSpace(req.Action)
+
+	segments := strings.Split(resource, "/")
+	for i, segment := range segments {
+		switch {
+		case segment == wildcardSegment:
+		case segment == wildcardRest:
+			if i != len(segments)-1 {
+				return nil, errors.NewValidationError(fmt.Sprintf("Invalid resource %q: '**' is only allowed as the last segment", req.Resource))
+			}
+		case !permissionSegmentPattern.MatchString(segment):
+			return nil, errors.NewValidationError(fmt.Sprintf("Invalid resource %q: use '/'-separated segments of lowercase letters, digits, '_' and '-', or '*' and '**'", req.Resource))
+		}
+	}
+	if action != wildcardAction && !permissionPartPattern.MatchString(action) {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid action %q: use lowercase letters, digits, '_' and '-', or '*'", req.Action))
+	}
+
+	return &entities.Permission{
+		Name:        resource + "." + action,
+		Resource:    resource,
+		Action:      action,
+		Description: strings.TrimSpace(req.Description),
+	}, nil
+}
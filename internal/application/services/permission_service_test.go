@@ -0,0 +1,228 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// rbacStore holds roles, permissions and role assignments for the fake role
+// and permission repositories, so that changes made through one are seen by
+// the other as they would be in the database. Changes through the fakes
+// increment version like the real repositories.
+type rbacStore struct {
+	permissions map[uint]*entities.Permission
+	roles       map[uint]*entities.Role
+	userRoles   map[uint][]uint
+	version     int64
+	// grantLoads counts the GetGrantsByUserID calls.
+	grantLoads int
+}
+
+func newRBACStore(permissions ...*entities.Permission) *rbacStore {
+	store := &rbacStore{
+		permissions: make(map[uint]*entities.Permission),
+		roles:       make(map[uint]*entities.Role),
+		userRoles:   make(map[uint][]uint),
+	}
+	for i, permission := range permissions {
+		permission.ID = uint(i + 1)
+		permission.Name = permission.Resource + "." + permission.Action
+		store.permissions[permission.ID] = permission
+	}
+	return store
+}
+
+func (s *rbacStore) addRole(id uint, name string, grants []uint, parents ...uint) {
+	role := &entities.Role{ID: id, Name: name}
+	for _, permissionID := range grants {
+		role.Permissions = append(role.Permissions, *s.permissions[permissionID])
+	}
+	for _, parentID := range parents {
+		role.Parents = append(role.Parents, entities.Role{ID: parentID, Name: s.roles[parentID].Name})
+	}
+	s.roles[id] = role
+}
+
+type fakeRoleRepository struct {
+	repositories.RoleRepository
+	store *rbacStore
+}
+
+func (r *fakeRoleRepository) GetByID(id uint) (*entities.Role, error) {
+	role, ok := r.store.roles[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return role, nil
+}
+
+func (r *fakeRoleRepository) List() ([]*entities.Role, error) {
+	roles := make([]*entities.Role, 0, len(r.store.roles))
+	for _, role := range r.store.roles {
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (r *fakeRoleRepository) AddPermissions(roleID uint, permissions []entities.Permission) error {
+	role := r.store.roles[roleID]
+	role.Permissions = append(role.Permissions, permissions...)
+	r.store.version++
+	return nil
+}
+
+func (r *fakeRoleRepository) RemovePermission(roleID, permissionID uint) error {
+	role := r.store.roles[roleID]
+	role.Permissions = slices.DeleteFunc(role.Permissions, func(permission entities.Permission) bool {
+		return permission.ID == permissionID
+	})
+	r.store.version++
+	return nil
+}
+
+func (r *fakeRoleRepository) AddDeniedPermissions(roleID uint, permissions []entities.Permission) error {
+	role := r.store.roles[roleID]
+	role.DeniedPermissions = append(role.DeniedPermissions, permissions...)
+	r.store.version++
+	return nil
+}
+
+func (r *fakeRoleRepository) RemoveDeniedPermission(roleID, permissionID uint) error {
+	role := r.store.roles[roleID]
+	role.DeniedPermissions = slices.DeleteFunc(role.DeniedPermissions, func(permission entities.Permission) bool {
+		return permission.ID == permissionID
+	})
+	r.store.version++
+	return nil
+}
+
+type fakePermissionRepository struct {
+	repositories.PermissionRepository
+	store *rbacStore
+}
+
+func (r *fakePermissionRepository) GetByID(id uint) (*entities.Permission, error) {
+	permission, ok := r.store.permissions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return permission, nil
+}
+
+// GetGrantsByUserID walks the user's roles and their ancestors like the
+// recursive query of the real repository.
+func (r *fakePermissionRepository) GetGrantsByUserID(userID uint) ([]*entities.PermissionGrant, error) {
+	r.store.grantLoads++
+	var grants []*entities.PermissionGrant
+	visited := make(map[uint]bool)
+	queue := append([]uint{}, r.store.userRoles[userID]...)
+	for len(queue) > 0 {
+		role := r.store.roles[queue[0]]
+		queue = queue[1:]
+		if visited[role.ID] {
+			continue
+		}
+		visited[role.ID] = true
+
+		for _, permission := range role.Permissions {
+			grants = append(grants, &entities.PermissionGrant{Permission: permission, Effect: entities.PermissionEffectAllow, Role: role.Name})
+		}
+		for _, permission := range role.DeniedPermissions {
+			grants = append(grants, &entities.PermissionGrant{Permission: permission, Effect: entities.PermissionEffectDeny, Role: role.Name})
+		}
+		for _, parent := range role.Parents {
+			queue = append(queue, parent.ID)
+		}
+	}
+	return grants, nil
+}
+
+func (r *fakePermissionRepository) Version() (int64, error) {
+	return r.store.version, nil
+}
+
+func TestRoleDenialsApplyToNextCheck(t *testing.T) {
+	store := newRBACStore(
+		&entities.Permission{Resource: "users/**", Action: "read"},
+		&entities.Permission{Resource: "users/executive/*", Action: "read"},
+	)
+	store.addRole(1, "support", []uint{1})
+	store.addRole(2, "manager", nil, 1)
+	store.userRoles[7] = []uint{1}
+	store.userRoles[8] = []uint{2}
+
+	permissionRepo := &fakePermissionRepository{store: store}
+	permissionService := NewPermissionService(permissionRepo, &fakeUserRepository{})
+	roleService := NewRoleService(&fakeRoleRepository{store: store}, permissionRepo)
+
+	check := func(userID uint, want bool, wantReason string) {
+		t.Helper()
+		decision, err := permissionService.Decide(userID, "users/executive/42", "read")
+		if err != nil {
+			t.Fatalf("Decide error = %v", err)
+		}
+		if decision.Allowed != want || decision.Reason != wantReason {
+			t.Errorf("Decide(%d) = %v %q, want %v %q", userID, decision.Allowed, decision.Reason, want, wantReason)
+		}
+	}
+
+	check(7, true, "Granted by users/**.read through role support")
+	check(8, true, "Granted by users/**.read through role support")
+
+	if _, err := roleService.AddDeniedPermissions(1, &dto.DeniedPermissionsRequest{PermissionIDs: []uint{2}}); err != nil {
+		t.Fatalf("AddDeniedPermissions error = %v", err)
+	}
+	check(7, false, "Denied by users/executive/*.read through role support")
+	check(8, false, "Denied by users/executive/*.read through role support")
+
+	if _, err := roleService.RemoveDeniedPermission(1, 2); err != nil {
+		t.Fatalf("RemoveDeniedPermission error = %v", err)
+	}
+	check(7, true, "Granted by users/**.read through role support")
+	check(8, true, "Granted by users/**.read through role support")
+}
+
+func TestGrantChangesApplyToNextCheck(t *testing.T) {
+	store := newRBACStore(&entities.Permission{Resource: "reports", Action: "read"})
+	store.addRole(1, "analyst", nil)
+	store.userRoles[7] = []uint{1}
+
+	permissionRepo := &fakePermissionRepository{store: store}
+	permissionService := NewPermissionService(permissionRepo, &fakeUserRepository{})
+	roleService := NewRoleService(&fakeRoleRepository{store: store}, permissionRepo)
+
+	check := func(want bool) {
+		t.Helper()
+		allowed, err := permissionService.CheckPermission(7, "reports", "read")
+		if err != nil {
+			t.Fatalf("CheckPermission error = %v", err)
+		}
+		if allowed != want {
+			t.Errorf("CheckPermission = %v, want %v", allowed, want)
+		}
+	}
+
+	check(false)
+	check(false)
+	if store.grantLoads != 1 {
+		t.Errorf("grants loaded %d times for an unchanged policy, want 1", store.grantLoads)
+	}
+
+	if _, err := roleService.AddPermissions(1, &dto.RolePermissionsRequest{PermissionIDs: []uint{1}}); err != nil {
+		t.Fatalf("AddPermissions error = %v", err)
+	}
+	check(true)
+
+	if _, err := roleService.RemovePermission(1, 1); err != nil {
+		t.Fatalf("RemovePermission error = %v", err)
+	}
+	check(false)
+	if store.grantLoads != 3 {
+		t.Errorf("grants loaded %d times, want once per RBAC version", store.grantLoads)
+	}
+}
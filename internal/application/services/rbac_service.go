@@ -0,0 +1,319 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type rbacService struct {
+	roleRepo       repositories.RoleRepository
+	permissionRepo repositories.PermissionRepository
+}
+
+func NewRBACService(
+	roleRepo repositories.RoleRepository,
+	permissionRepo repositories.PermissionRepository,
+) services.RBACService {
+	return &rbacService{
+		roleRepo:       roleRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+func (s *rbacService) ExportManifest() (*dto.RBACManifest, error) {
+	permissions, err := s.permissionRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list permissions: %w", err)
+	}
+
+	roles, err := s.roleRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list roles: %w", err)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+
+	manifest := &dto.RBACManifest{
+		Permissions: make([]dto.PermissionRequest, len(permissions)),
+		Roles:       make([]dto.ManifestRole, len(roles)),
+	}
+	for i, permission := range permissions {
+		manifest.Permissions[i] = dto.PermissionRequest{
+			Resource:    permission.Resource,
+			Action:      permission.Action,
+			Description: permission.Description,
+		}
+	}
+	for i, role := range roles {
+		granted := make([]string, len(role.Permissions))
+		for j, permission := range role.Permissions {
+			granted[j] = permission.Name
+		}
+		sort.Strings(granted)
+
+		var denied []string
+		for _, permission := range role.DeniedPermissions {
+			denied = append(denied, permission.Name)
+		}
+		sort.Strings(denied)
+
+		var parents []string
+		for _, parent := range role.Parents {
+			parents = append(parents, parent.Name)
+		}
+		sort.Strings(parents)
+
+		manifest.Roles[i] = dto.ManifestRole{
+			Name:        role.Name,
+			Description: role.Description,
+			Inherits:    parents,
+			Permissions: granted,
+			Denies:      denied,
+		}
+	}
+	return manifest, nil
+}
+
+func (s *rbacService) PlanManifest(manifest *dto.RBACManifest) (*dto.RBACPlan, error) {
+	plan, _, _, err := s.plan(manifest)
+	return plan, err
+}
+
+func (s *rbacService) ApplyManifest(manifest *dto.RBACManifest) (*dto.RBACPlan, error) {
+	plan, permissions, roles, err := s.plan(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.HasChanges {
+		return plan, nil
+	}
+
+	if err := s.roleRepo.Reconcile(permissions, roles); err != nil {
+		return nil, fmt.Errorf("Failed to apply RBAC manifest: %w", err)
+	}
+	return plan, nil
+}
+
+// plan validates the manifest and compares it with the catalog. Besides the
+// plan it returns the manifest as entities; the roles carry the names of
+// the permissions they should grant and deny and of the roles they inherit
+// from.
+func (s *rbacService) plan(manifest *dto.RBACManifest) (*dto.RBACPlan, []*entities.Permission, []*entities.Role, error) {
+	existingPermissions, err := s.permissionRepo.List()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to list permissions: %w", err)
+	}
+	existingRoles, err := s.roleRepo.List()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to list roles: %w", err)
+	}
+
+	plan := &dto.RBACPlan{
+		CreatePermissions: []string{},
+		UpdatePermissions: []string{},
+		CreateRoles:       []string{},
+		UpdateRoles:       []string{},
+		Grant:             []dto.RoleGrant{},
+		Revoke:            []dto.RoleGrant{},
+		Deny:              []dto.RoleGrant{},
+		Undeny:            []dto.RoleGrant{},
+		AddParents:        []dto.RoleParent{},
+		RemoveParents:     []dto.RoleParent{},
+	}
+
+	permissionsByName := make(map[string]*entities.Permission, len(existingPermissions))
+	for _, permission := range existingPermissions {
+		permissionsByName[permission.Name] = permission
+	}
+
+	declared := make(map[string]bool, len(manifest.Permissions))
+	permissions := make([]*entities.Permission, 0, len(manifest.Permissions))
+	for i := range manifest.Permissions {
+		permission, err := newPermission(&manifest.Permissions[i])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if declared[permission.Name] {
+			return nil, nil, nil, errors.NewValidationError(fmt.Sprintf("Permission %s is declared twice", permission.Name))
+		}
+		declared[permission.Name] = true
+		permissions = append(permissions, permission)
+
+		if existing, ok := permissionsByName[permission.Name]; !ok {
+			plan.CreatePermissions = append(plan.CreatePermissions, permission.Name)
+		} else if existing.Description != permission.Description {
+			plan.UpdatePermissions = append(plan.UpdatePermissions, permission.Name)
+		}
+	}
+
+	roles := make([]*entities.Role, 0, len(manifest.Roles))
+	declaredRoles := make(map[string]bool, len(manifest.Roles))
+	for _, manifestRole := range manifest.Roles {
+		name := strings.TrimSpace(manifestRole.Name)
+		if name == "" {
+			return nil, nil, nil, errors.NewValidationError("Role name is required")
+		}
+		if declaredRoles[name] {
+			return nil, nil, nil, errors.NewValidationError(fmt.Sprintf("Role %s is declared twice", name))
+		}
+		declaredRoles[name] = true
+
+		role := &entities.Role{Name: name, Description: manifestRole.Description}
+		granted := make(map[string]bool, len(manifestRole.Permissions))
+		for _, permissionName := range manifestRole.Permissions {
+			if !declared[permissionName] && permissionsByName[permissionName] == nil {
+				return nil, nil, nil, errors.NewValidationError(fmt.Sprintf("Role %s grants unknown permission %s", name, permissionName))
+			}
+			if !granted[permissionName] {
+				granted[permissionName] = true
+				role.Permissions = append(role.Permissions, entities.Permission{Name: permissionName})
+			}
+		}
+
+		denied := make(map[string]bool, len(manifestRole.Denies))
+		for _, permissionName := range manifestRole.Denies {
+			if !declared[permissionName] && permissionsByName[permissionName] == nil {
+				return nil, nil, nil, errors.NewValidationError(fmt.Sprintf("Role %s denies unknown permission %s", name, permissionName))
+			}
+			if granted[permissionName] {
+				return nil, nil, nil, errors.NewValidationError(fmt.Sprintf("Role %s both grants and denies %s", name, permissionName))
+			}
+			if !denied[permissionName] {
+				denied[permissionName] = true
+				role.DeniedPermissions = append(role.DeniedPermissions, entities.Permission{Name: permissionName})
+			}
+		}
+		roles = append(roles, role)
+	}
+
+	rolesByName := make(map[string]*entities.Role, len(existingRoles))
+	for _, role := range existingRoles {
+		rolesByName[role.Name] = role
+	}
+
+	// graph is the hierarchy once the manifest is applied: the stored roles,
+	// with those in the manifest replaced.
+	graph := newRoleGraph(existingRoles)
+	for i, role := range roles {
+		inherited := make(map[string]bool, len(manifest.Roles[i].Inherits))
+		for _, parentName := range manifest.Roles[i].Inherits {
+			if !declaredRoles[parentName] && rolesByName[parentName] == nil {
+				return nil, nil, nil, errors.NewValidationError(fmt.Sprintf("Role %s inherits from unknown role %s", role.Name, parentName))
+			}
+			if !inherited[parentName] {
+				inherited[parentName] = true
+				role.Parents = append(role.Parents, entities.Role{Name: parentName})
+			}
+		}
+		graph[role.Name] = role
+	}
+	for _, role := range roles {
+		for _, parent := range role.Parents {
+			if err := graph.checkParent(role.Name, parent.Name); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+
+	for _, role := range roles {
+		name := role.Name
+		granted := make(map[string]bool, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			granted[permission.Name] = true
+		}
+
+		existing, ok := rolesByName[name]
+		if !ok {
+			plan.CreateRoles = append(plan.CreateRoles, name)
+			existing = &entities.Role{}
+		} else if existing.Description != role.Description {
+			plan.UpdateRoles = append(plan.UpdateRoles, name)
+		}
+
+		current := make(map[string]bool, len(existing.Permissions))
+		for _, permission := range existing.Permissions {
+			current[permission.Name] = true
+			if !granted[permission.Name] {
+				plan.Revoke = append(plan.Revoke, dto.RoleGrant{Role: name, Permission: permission.Name})
+			}
+		}
+		for _, permission := range role.Permissions {
+			if !current[permission.Name] {
+				plan.Grant = append(plan.Grant, dto.RoleGrant{Role: name, Permission: permission.Name})
+			}
+		}
+
+		denied := make(map[string]bool, len(role.DeniedPermissions))
+		for _, permission := range role.DeniedPermissions {
+			denied[permission.Name] = true
+		}
+		currentDenied := make(map[string]bool, len(existing.DeniedPermissions))
+		for _, permission := range existing.DeniedPermissions {
+			currentDenied[permission.Name] = true
+			if !denied[permission.Name] {
+				plan.Undeny = append(plan.Undeny, dto.RoleGrant{Role: name, Permission: permission.Name})
+			}
+		}
+		for _, permission := range role.DeniedPermissions {
+			if !currentDenied[permission.Name] {
+				plan.Deny = append(plan.Deny, dto.RoleGrant{Role: name, Permission: permission.Name})
+			}
+		}
+
+		inherited := make(map[string]bool, len(role.Parents))
+		for _, parent := range role.Parents {
+			inherited[parent.Name] = true
+		}
+		currentParents := make(map[string]bool, len(existing.Parents))
+		for _, parent := range existing.Parents {
+			currentParents[parent.Name] = true
+			if !inherited[parent.Name] {
+				plan.RemoveParents = append(plan.RemoveParents, dto.RoleParent{Role: name, Parent: parent.Name})
+			}
+		}
+		for _, parent := range role.Parents {
+			if !currentParents[parent.Name] {
+				plan.AddParents = append(plan.AddParents, dto.RoleParent{Role: name, Parent: parent.Name})
+			}
+		}
+	}
+
+	sortRoleGrants(plan.Grant)
+	sortRoleGrants(plan.Revoke)
+	sortRoleGrants(plan.Deny)
+	sortRoleGrants(plan.Undeny)
+	sortRoleParents(plan.AddParents)
+	sortRoleParents(plan.RemoveParents)
+	plan.HasChanges = len(plan.CreatePermissions) > 0 || len(plan.UpdatePermissions) > 0 ||
+		len(plan.CreateRoles) > 0 || len(plan.UpdateRoles) > 0 ||
+		len(plan.Grant) > 0 || len(plan.Revoke) > 0 ||
+		len(plan.Deny) > 0 || len(plan.Undeny) > 0 ||
+		len(plan.AddParents) > 0 || len(plan.RemoveParents) > 0
+
+	return plan, permissions, roles, nil
+}
+
+func sortRoleGrants(grants []dto.RoleGrant) {
+	sort.Slice(grants, func(i, j int) bool {
+		if grants[i].Role != grants[j].Role {
+			return grants[i].Role < grants[j].Role
+		}
+		return grants[i].Permission < grants[j].Permission
+	})
+}
+
+func sortRoleParents(parents []dto.RoleParent) {
+	sort.Slice(parents, func(i, j int) bool {
+		if parents[i].Role != parents[j].Role {
+			return parents[i].Role < parents[j].Role
+		}
+		return parents[i].Parent < parents[j].Parent
+	})
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type roleService struct {
+	roleRepo       repositories.RoleRepository
+	permissionRepo repositories.PermissionRepository
+}
+
+func NewRoleService(
+	roleRepo repositories.RoleRepository,
+	permissionRepo repositories.PermissionRepository,
+) services.RoleService {
+	return &roleService{
+		roleRepo:       roleRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+func (s *roleService) ListRoles() ([]dto.RoleResponse, error) {
+	roles, err := s.roleRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list roles: %w", err)
+	}
+
+	graph := newRoleGraph(roles)
+	responses := make([]dto.RoleResponse, len(roles))
+	for i, role := range roles {
+		responses[i] = graph.mapRole(role)
+	}
+	return responses, nil
+}
+
+func (s *roleService) GetRole(roleID uint) (*dto.RoleResponse, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := s.loadRoleGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	response := graph.mapRole(role)
+	return &response, nil
+}
+
+func (s *roleService) CreateRole(req *dto.CreateRoleRequest) (*dto.RoleResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameAvailable(name, 0); err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.getPermissions(req.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new role has no descendants yet, so its parents cannot form a cycle.
+	parents, err := s.getParents(req.ParentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &entities.Role{
+		Name:        name,
+		Description: req.Description,
+		Permissions: permissions,
+		Parents:     parents,
+	}
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, fmt.Errorf("Failed to create role: %w", err)
+	}
+
+	return s.GetRole(role.ID)
+}
+
+func (s *roleService) UpdateRole(roleID uint, req *dto.UpdateRoleRequest) (*dto.RoleResponse, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameAvailable(name, role.ID); err != nil {
+		return nil, err
+	}
+
+	role.Name = name
+	role.Description = req.Description
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, fmt.Errorf("Failed to update role: %w", err)
+	}
+
+	return s.GetRole(role.ID)
+}
+
+func (s *roleService) DeleteRole(roleID uint, force bool) error {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		users, err := s.roleRepo.CountUsers(role.ID)
+		if err != nil {
+			return fmt.Errorf("Failed to count role users: %w", err)
+		}
+		if users > 0 {
+			return errors.NewConflictError(fmt.Sprintf("Role is assigned to %d user(s); use force to delete it anyway", users))
+		}
+	}
+
+	if err := s.roleRepo.Delete(role.ID); err != nil {
+		return fmt.Errorf("Failed to delete role: %w", err)
+	}
+
+	return nil
+}
+
+func (s *roleService) AddPermissions(roleID uint, req *dto.RolePermissionsRequest) (*dto.RoleResponse, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.getPermissions(req.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.AddPermissions(role.ID, permissions); err != nil {
+		return nil, fmt.Errorf("Failed to add permissions: %w", err)
+	}
+
+	return s.GetRole(role.ID)
+}
+
+func (s *roleService) RemovePermission(roleID, permissionID uint) (*dto.RoleResponse, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := false
+	for _, permission := range role.Permissions {
+		if permission.ID == permissionID {
+			granted = true
+			break
+		}
+	}
+	if !granted {
+		return nil, errors.NewValidationError("Role does not have this permission")
+	}
+
+	if err := s.roleRepo.RemovePermission(role.ID, permissionID); err != nil {
+		return nil, fmt.Errorf("Failed to remove permission: %w", err)
+	}
+
+	return s.GetRole(role.ID)
+}
+
+func (s *roleService) AddDeniedPermissions(roleID uint, req *dto.DeniedPermissionsRequest) (*dto.RoleResponse, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.getPermissions(req.PermissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.AddDeniedPermissions(role.ID, permissions); err != nil {
+		return nil, fmt.Errorf("Failed to deny permissions: %w", err)
+	}
+
+	return s.GetRole(role.ID)
+}
+
+func (s *roleService) RemoveDeniedPermission(roleID, permissionID uint) (*dto.RoleResponse, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	denied := false
+	for _, permission := range role.DeniedPermissions {
+		if permission.ID == permissionID {
+			denied = true
+			break
+		}
+	}
+	if !denied {
+		return nil, errors.NewValidationError("Role does not deny this permission")
+	}
+
+	if err := s.roleRepo.RemoveDeniedPermission(role.ID, permissionID); err != nil {
+		return nil, fmt.Errorf("Failed to remove denial: %w", err)
+	}
+
+	return s.GetRole(role.ID)
+}
+
+func (s *roleService) SetParents(roleID uint, req *dto.RoleParentsRequest) (*dto.RoleResponse, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	parents, err := s.getParents(req.ParentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := s.loadRoleGraph()
+	if err != nil {
+		return nil, err
+	}
+	for _, parent := range parents {
+		if err := graph.checkParent(role.Name, parent.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.roleRepo.SetParents(role.ID, parents); err != nil {
+		return nil, fmt.Errorf("Failed to set role parents: %w", err)
+	}
+
+	return s.GetRole(role.ID)
+}
+
+func (s *roleService) getRole(roleID uint) (*entities.Role, error) {
+	role, err := s.roleRepo.GetByID(roleID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("Role not found")
+		}
+		return nil, fmt.Errorf("Failed to get role: %w", err)
+	}
+	return role, nil
+}
+
+// checkNameAvailable fails if another role than roleID uses name.
+func (s *roleService) checkNameAvailable(name string, roleID uint) error {
+	if name == "" {
+		return errors.NewValidationError("Role name is required")
+	}
+
+	existing, err := s.roleRepo.GetByName(name)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("Failed to get role: %w", err)
+	}
+	if existing.ID != roleID {
+		return errors.NewConflictError("Role name already exists")
+	}
+	return nil
+}
+
+func (s *roleService) getPermissions(ids []uint) ([]entities.Permission, error) {
+	permissions := make([]entities.Permission, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		permission, err := s.permissionRepo.GetByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, errors.NewValidationError(fmt.Sprintf("Permission %d not found", id))
+			}
+			return nil, fmt.Errorf("Failed to get permission: %w", err)
+		}
+		permissions = append(permissions, *permission)
+	}
+	return permissions, nil
+}
+
+func (s *roleService) getParents(ids []uint) ([]entities.Role, error) {
+	parents := make([]entities.Role, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		parent, err := s.roleRepo.GetByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, errors.NewValidationError(fmt.Sprintf("Role %d not found", id))
+			}
+			return nil, fmt.Errorf("Failed to get role: %w", err)
+		}
+		parents = append(parents, entities.Role{ID: parent.ID, Name: parent.Name})
+	}
+	return parents, nil
+}
+
+func (s *roleService) loadRoleGraph() (roleGraph, error) {
+	roles, err := s.roleRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list roles: %w", err)
+	}
+	return newRoleGraph(roles), nil
+}
+
+// roleGraph indexes roles by name to walk the inheritance hierarchy. The
+// roles need their permissions and parents loaded.
+type roleGraph map[string]*entities.Role
+
+func newRoleGraph(roles []*entities.Role) roleGraph {
+	graph := make(roleGraph, len(roles))
+	for _, role := range roles {
+		graph[role.Name] = role
+	}
+	return graph
+}
+
+// ancestors returns the roles that the named role inherits from, nearest
+// first. Every role is visited once, so a cycle cannot loop forever.
+func (g roleGraph) ancestors(name string) []*entities.Role {
+	var ancestors []*entities.Role
+	visited := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		role := g[queue[0]]
+		queue = queue[1:]
+		if role == nil {
+			continue
+		}
+
+		for _, parent := range role.Parents {
+			if visited[parent.Name] {
+				continue
+			}
+			visited[parent.Name] = true
+			if ancestor := g[parent.Name]; ancestor != nil {
+				ancestors = append(ancestors, ancestor)
+			}
+			queue = append(queue, parent.Name)
+		}
+	}
+	return ancestors
+}
+
+// checkParent fails if letting role inherit from parent would close a cycle.
+func (g roleGraph) checkParent(role, parent string) error {
+	if role == parent {
+		return errors.NewValidationError(fmt.Sprintf("Role %s cannot inherit from itself", role))
+	}
+	for _, ancestor := range g.ancestors(parent) {
+		if ancestor.Name == role {
+			return errors.NewValidationError(fmt.Sprintf("Role %s cannot inherit from %s, which already inherits from it", role, parent))
+		}
+	}
+	return nil
+}
+
+// mapRole maps the role with the permissions and denials it inherits from
+// its ancestors.
+func (g roleGraph) mapRole(role *entities.Role) dto.RoleResponse {
+	response := mapRoleToResponse(role)
+	ancestors := g.ancestors(role.Name)
+	response.InheritedPermissions = inheritedPermissions(role, ancestors, func(r *entities.Role) []entities.Permission {
+		return r.Permissions
+	})
+	response.InheritedDeniedPermissions = inheritedPermissions(role, ancestors, func(r *entities.Role) []entities.Permission {
+		return r.DeniedPermissions
+	})
+	return response
+}
+
+// inheritedPermissions lists the permissions of the ancestors, as selected
+// by permissions, that the role does not have itself.
+func inheritedPermissions(role *entities.Role, ancestors []*entities.Role, permissions func(*entities.Role) []entities.Permission) []dto.InheritedPermissionResponse {
+	var inherited []dto.InheritedPermissionResponse
+	seen := make(map[uint]bool)
+	for _, permission := range permissions(role) {
+		seen[permission.ID] = true
+	}
+	for _, ancestor := range ancestors {
+		for _, permission := range permissions(ancestor) {
+			if seen[permission.ID] {
+				continue
+			}
+			seen[permission.ID] = true
+			inherited = append(inherited, dto.InheritedPermissionResponse{
+				PermissionResponse: mapPermissionToResponse(&permission),
+				InheritedFrom:      ancestor.Name,
+			})
+		}
+	}
+	return inherited
+}
+
+func mapRoleToResponse(role *entities.Role) dto.RoleResponse {
+	permissions := make([]dto.PermissionResponse, len(role.Permissions))
+	for i, perm := range role.Permissions {
+		permissions[i] = mapPermissionToResponse(&perm)
+	}
+
+	var denied []dto.PermissionResponse
+	for _, perm := range role.DeniedPermissions {
+		denied = append(denied, mapPermissionToResponse(&perm))
+	}
+
+	var parents []dto.RoleReference
+	for _, parent := range role.Parents {
+		parents = append(parents, dto.RoleReference{ID: parent.ID, Name: parent.Name})
+	}
+
+	return dto.RoleResponse{
+		ID:                role.ID,
+		Name:              role.Name,
+		Description:       role.Description,
+		Parents:           parents,
+		Permissions:       permissions,
+		DeniedPermissions: denied,
+	}
+}
+
+func mapPermissionToResponse(permission *entities.Permission) dto.PermissionResponse {
+	return dto.PermissionResponse{
+		ID:          permission.ID,
+		Name:        permission.Name,
+		Resource:    permission.Resource,
+		Action:      permission.Action,
+		Description: permission.Description,
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type sessionService struct {
+	sessionRepo      repositories.SessionRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+}
+
+func NewSessionService(
+	sessionRepo repositories.SessionRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+) services.SessionService {
+	return &sessionService{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+	}
+}
+
+func (s *sessionService) ListSessions(userID, currentSessionID uint) ([]dto.SessionResponse, error) {
+	sessions, err := s.sessionRepo.ListActiveByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list sessions: %w", err)
+	}
+
+	responses := make([]dto.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = dto.SessionResponse{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		}
+	}
+
+	return responses, nil
+}
+
+func (s *sessionService) RevokeSession(userID, sessionID uint) error {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFoundError("Session not found")
+		}
+		return fmt.Errorf("Failed to get session: %w", err)
+	}
+
+	// Report sessions of other users as missing rather than forbidden so
+	// session IDs cannot be probed.
+	if session.UserID != userID {
+		return errors.NewNotFoundError("Session not found")
+	}
+
+	return revokeSession(s.sessionRepo, s.refreshTokenRepo, session)
+}
+
+func (s *sessionService) RevokeOtherSessions(userID, currentSessionID uint) error {
+	sessions, err := s.sessionRepo.ListActiveByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("Failed to list sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		if session.ID == currentSessionID {
+			continue
+		}
+		if err := revokeSession(s.sessionRepo, s.refreshTokenRepo, session); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sessionService) RevokeAllSessions(userID uint) error {
+	return s.RevokeOtherSessions(userID, 0)
+}
+
+// revokeSession ends the session and invalidates every refresh token issued
+// for it. Access tokens are rejected by the auth middleware once their
+// session is no longer active.
+func revokeSession(
+	sessionRepo repositories.SessionRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	session *entities.Session,
+) error {
+	if err := sessionRepo.Revoke(session.ID); err != nil {
+		return fmt.Errorf("Failed to revoke session: %w", err)
+	}
+
+	if err := refreshTokenRepo.RevokeFamily(session.FamilyID); err != nil {
+		return fmt.Errorf("Failed to revoke session tokens: %w", err)
+	}
+
+	return nil
+}
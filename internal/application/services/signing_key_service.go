@@ -0,0 +1,179 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/domain/services"
+	"auth-system/internal/infrastructure/security"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type signingKeyService struct {
+	signingKeyRepo   repositories.SigningKeyRepository
+	keyring          *security.Keyring
+	encrypter        *security.Encrypter
+	staticKeys       []*security.SigningKey
+	algorithm        string
+	verifyWindow     time.Duration
+	rotationInterval time.Duration
+}
+
+// NewSigningKeyService manages the database-backed keys in the keyring.
+// staticKeys come from configuration; the first one signs tokens until a
+// managed key has been activated. Retired keys stay valid for verifyWindow,
+// which must cover the longest token lifetime.
+func NewSigningKeyService(
+	signingKeyRepo repositories.SigningKeyRepository,
+	keyring *security.Keyring,
+	encrypter *security.Encrypter,
+	staticKeys []*security.SigningKey,
+	algorithm string,
+	verifyWindow time.Duration,
+	rotationInterval time.Duration,
+) services.SigningKeyService {
+	return &signingKeyService{
+		signingKeyRepo:   signingKeyRepo,
+		keyring:          keyring,
+		encrypter:        encrypter,
+		staticKeys:       staticKeys,
+		algorithm:        algorithm,
+		verifyWindow:     verifyWindow,
+		rotationInterval: rotationInterval,
+	}
+}
+
+func (s *signingKeyService) SyncKeys() error {
+	stored, err := s.signingKeyRepo.ListUsable(time.Now())
+	if err != nil {
+		return fmt.Errorf("Failed to list signing keys: %w", err)
+	}
+
+	active := s.staticKeys[0]
+	keys := append([]*security.SigningKey{}, s.staticKeys...)
+
+	for _, record := range stored {
+		key, err := s.decodeKey(record)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, key)
+		if record.Status == entities.SigningKeyStatusActive {
+			active = key
+		}
+	}
+
+	s.keyring.Replace(active, keys...)
+	return nil
+}
+
+func (s *signingKeyService) RotateSigningKey() (*dto.SigningKeyResponse, error) {
+	record, _, err := s.rotate(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	response := mapSigningKeyToResponse(record)
+	return &response, nil
+}
+
+func (s *signingKeyService) RotateIfDue() (bool, error) {
+	if s.rotationInterval <= 0 {
+		return false, nil
+	}
+
+	active, err := s.signingKeyRepo.GetActive()
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, fmt.Errorf("Failed to get active signing key: %w", err)
+	}
+	if active != nil && time.Since(active.ActivatedAt) < s.rotationInterval {
+		return false, nil
+	}
+
+	_, rotated, err := s.rotate(time.Now().Add(-s.rotationInterval))
+	return rotated, err
+}
+
+func (s *signingKeyService) ListSigningKeys() ([]dto.SigningKeyResponse, error) {
+	stored, err := s.signingKeyRepo.ListUsable(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list signing keys: %w", err)
+	}
+
+	responses := make([]dto.SigningKeyResponse, len(stored))
+	for i, record := range stored {
+		responses[i] = mapSigningKeyToResponse(record)
+	}
+
+	return responses, nil
+}
+
+func (s *signingKeyService) PruneExpiredKeys() error {
+	if _, err := s.signingKeyRepo.DeleteExpired(time.Now()); err != nil {
+		return fmt.Errorf("Failed to delete expired signing keys: %w", err)
+	}
+	return nil
+}
+
+func (s *signingKeyService) rotate(activatedBefore time.Time) (*entities.SigningKey, bool, error) {
+	key, err := security.GenerateSigningKey("", s.algorithm)
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to generate signing key: %w", err)
+	}
+
+	pemBytes, err := key.EncodePEM()
+	if err != nil {
+		return nil, false, err
+	}
+
+	encrypted, err := s.encrypter.Encrypt(pemBytes)
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to encrypt signing key: %w", err)
+	}
+
+	record := &entities.SigningKey{
+		KID:        key.ID,
+		Algorithm:  key.Method.Alg(),
+		PrivateKey: encrypted,
+	}
+
+	rotated, err := s.signingKeyRepo.Rotate(record, time.Now().Add(s.verifyWindow), activatedBefore)
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to rotate signing key: %w", err)
+	}
+
+	if err := s.SyncKeys(); err != nil {
+		return nil, false, err
+	}
+
+	return record, rotated, nil
+}
+
+func (s *signingKeyService) decodeKey(record *entities.SigningKey) (*security.SigningKey, error) {
+	pemBytes, err := s.encrypter.Decrypt(record.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decrypt signing key %s: %w", record.KID, err)
+	}
+
+	key, err := security.ParseSigningKeyPEM(record.KID, pemBytes, record.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse signing key %s: %w", record.KID, err)
+	}
+
+	return key, nil
+}
+
+func mapSigningKeyToResponse(record *entities.SigningKey) dto.SigningKeyResponse {
+	return dto.SigningKeyResponse{
+		KID:         record.KID,
+		Algorithm:   record.Algorithm,
+		Status:      record.Status,
+		ActivatedAt: record.ActivatedAt,
+		RetiredAt:   record.RetiredAt,
+		VerifyUntil: record.VerifyUntil,
+	}
+}
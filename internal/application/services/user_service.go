@@ -2,35 +2,275 @@ package services
 
 import (
 	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
 	"auth-system/internal/domain/repositories"
 	"auth-system/internal/domain/services"
 	"auth-system/internal/infrastructure/security"
 	"auth-system/pkg/errors"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type userService struct {
-	userRepo        repositories.UserRepository
-	roleRepo        repositories.RoleRepository
-	passwordManager *security.PasswordManager
-	authService     *authService
+	userRepo                 repositories.UserRepository
+	roleRepo                 repositories.RoleRepository
+	securityEventRepo        repositories.SecurityEventRepository
+	passwordManager          *security.PasswordManager
+	sessionService           services.SessionService
+	emailVerificationService services.EmailVerificationService
+	passwordResetService     services.PasswordResetService
+	authService              *authService
 }
 
 func NewUserService(
 	userRepo repositories.UserRepository,
 	roleRepo repositories.RoleRepository,
+	securityEventRepo repositories.SecurityEventRepository,
 	passwordManager *security.PasswordManager,
+	sessionService services.SessionService,
+	emailVerificationService services.EmailVerificationService,
+	passwordResetService services.PasswordResetService,
 ) services.UserService {
 	return &userService{
-		userRepo:        userRepo,
-		roleRepo:        roleRepo,
-		passwordManager: passwordManager,
-		authService:     &authService{userRepo: userRepo, roleRepo: roleRepo},
+		userRepo:                 userRepo,
+		roleRepo:                 roleRepo,
+		securityEventRepo:        securityEventRepo,
+		passwordManager:          passwordManager,
+		sessionService:           sessionService,
+		emailVerificationService: emailVerificationService,
+		passwordResetService:     passwordResetService,
+		authService:              &authService{userRepo: userRepo, roleRepo: roleRepo},
 	}
 }
 
+const (
+	defaultUsersPerPage = 20
+	dateLayout          = "2006-01-02"
+)
+
+func (s *userService) ListUsers(req *dto.ListUsersRequest) (*dto.PaginatedResponse, error) {
+	page := max(req.Page, 1)
+	perPage := usersPerPage(req)
+
+	filter, err := userFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	users, total, err := s.userRepo.List(filter, (page-1)*perPage, perPage)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list users: %w", err)
+	}
+
+	return &dto.PaginatedResponse{
+		Data:       s.mapUsersToResponse(users),
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: int(math.Ceil(float64(total) / float64(perPage))),
+	}, nil
+}
+
+func (s *userService) ListUsersByCursor(req *dto.ListUsersRequest) (*dto.CursorPaginatedResponse, error) {
+	if req.After != "" && req.Before != "" {
+		return nil, errors.NewValidationError("Use either after or before, not both")
+	}
+
+	perPage := usersPerPage(req)
+
+	filter, err := userFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch filter.SortBy {
+	case "created_at", "email", "id":
+	default:
+		return nil, errors.NewValidationError("Cursor pagination supports sorting by created_at, email or id")
+	}
+
+	// Cursors remember the order they were issued for, so one from another
+	// listing is rejected instead of silently skipping rows.
+	order := filter.SortBy
+	if filter.SortDesc {
+		order = "-" + order
+	}
+
+	backward := req.Before != ""
+	var cursor *repositories.UserCursor
+	if token := req.After + req.Before; token != "" {
+		if cursor, err = decodeUserCursor(token, order); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra row tells whether there is another page.
+	users, err := s.userRepo.ListPage(filter, cursor, backward, perPage+1)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list users: %w", err)
+	}
+
+	hasMore := len(users) > perPage
+	if hasMore {
+		if backward {
+			users = users[1:]
+		} else {
+			users = users[:perPage]
+		}
+	}
+
+	response := &dto.CursorPaginatedResponse{
+		Data:    s.mapUsersToResponse(users),
+		PerPage: perPage,
+	}
+
+	hasNext, hasPrev := hasMore, cursor != nil
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+
+	if len(users) > 0 {
+		if hasNext {
+			if response.NextCursor, err = encodeUserCursor(users[len(users)-1], order); err != nil {
+				return nil, err
+			}
+		}
+		if hasPrev {
+			if response.PrevCursor, err = encodeUserCursor(users[0], order); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return response, nil
+}
+
+func (s *userService) mapUsersToResponse(users []*entities.User) []dto.UserResponse {
+	responses := make([]dto.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = s.authService.mapUserToResponse(user)
+	}
+	return responses
+}
+
+func usersPerPage(req *dto.ListUsersRequest) int {
+	if req.PerPage == 0 {
+		return defaultUsersPerPage
+	}
+	return req.PerPage
+}
+
+// userFilter turns the listing query parameters into a repository filter.
+func userFilter(req *dto.ListUsersRequest) (repositories.UserFilter, error) {
+	filter := repositories.UserFilter{
+		Search:   strings.TrimSpace(req.Search),
+		Role:     strings.TrimSpace(req.Role),
+		IsActive: req.IsActive,
+		Deleted:  req.Deleted,
+		SortBy:   "created_at",
+		SortDesc: true,
+	}
+
+	if req.Sort != "" {
+		filter.SortBy = strings.TrimPrefix(req.Sort, "-")
+		filter.SortDesc = strings.HasPrefix(req.Sort, "-")
+		switch filter.SortBy {
+		case "created_at", "email", "first_name", "last_name", "id":
+		default:
+			return filter, errors.NewValidationError("Invalid sort field")
+		}
+	}
+
+	if req.CreatedFrom != "" {
+		from, _, err := parseDateParam(req.CreatedFrom)
+		if err != nil {
+			return filter, errors.NewValidationError("Invalid created_from date")
+		}
+		filter.CreatedFrom = &from
+	}
+
+	if req.CreatedTo != "" {
+		to, dateOnly, err := parseDateParam(req.CreatedTo)
+		if err != nil {
+			return filter, errors.NewValidationError("Invalid created_to date")
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		} else {
+			to = to.Add(time.Nanosecond)
+		}
+		filter.CreatedBefore = &to
+	}
+
+	return filter, nil
+}
+
+// userCursorToken is the JSON inside the opaque cursors handed to clients.
+type userCursorToken struct {
+	Order     string     `json:"o"`
+	CreatedAt *time.Time `json:"c,omitempty"`
+	Email     string     `json:"e,omitempty"`
+	ID        uint       `json:"i"`
+}
+
+func encodeUserCursor(user *entities.User, order string) (string, error) {
+	token := userCursorToken{Order: order, ID: user.ID}
+	switch strings.TrimPrefix(order, "-") {
+	case "created_at":
+		token.CreatedAt = &user.CreatedAt
+	case "email":
+		token.Email = user.Email
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("Failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeUserCursor(cursor, order string) (*repositories.UserCursor, error) {
+	invalid := errors.NewValidationError("Invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var token userCursorToken
+	if err := json.Unmarshal(data, &token); err != nil || token.ID == 0 {
+		return nil, invalid
+	}
+	if token.Order != order {
+		return nil, errors.NewValidationError("Cursor does not match the sort order")
+	}
+
+	userCursor := &repositories.UserCursor{ID: token.ID, Email: token.Email}
+	if token.CreatedAt != nil {
+		userCursor.CreatedAt = *token.CreatedAt
+	} else if strings.TrimPrefix(order, "-") == "created_at" {
+		return nil, invalid
+	}
+	return userCursor, nil
+}
+
+// parseDateParam accepts a date or an RFC 3339 timestamp and reports
+// whether it was a plain date.
+func parseDateParam(value string) (time.Time, bool, error) {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, true, nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	return timestamp, false, err
+}
+
 func (s *userService) GetProfile(userID uint) (*dto.UserResponse, error) {
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
@@ -55,6 +295,9 @@ func (s *userService) UpdateProfile(userID uint, req *dto.UpdateUserRequest) (*d
 
 	user.FirstName = req.FirstName
 	user.LastName = req.LastName
+	if req.Locale != "" {
+		user.Locale = req.Locale
+	}
 
 	if err := s.userRepo.Update(user); err != nil {
 		return nil, fmt.Errorf("Failed to update user: %w", err)
@@ -108,8 +351,7 @@ func (s *userService) AssignRole(userID uint, roleID uint) error {
 		}
 	}
 
-	user.Roles = append(user.Roles, *role)
-	return s.userRepo.Update(user)
+	return s.userRepo.AddRole(user.ID, role.ID)
 }
 
 func (s *userService) RemoveRole(userID uint, roleID uint) error {
@@ -119,12 +361,283 @@ func (s *userService) RemoveRole(userID uint, roleID uint) error {
 	}
 
 	// Find and remove the role
-	for i, role := range user.Roles {
+	for _, role := range user.Roles {
 		if role.ID == roleID {
-			user.Roles = append(user.Roles[:i], user.Roles[i+1:]...)
-			return s.userRepo.Update(user)
+			return s.userRepo.RemoveRole(user.ID, roleID)
 		}
 	}
 
 	return errors.NewValidationError("User does not have this role")
 }
+
+func (s *userService) UnlockUser(userID uint) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFoundError("User not found")
+		}
+		return fmt.Errorf("Failed to get user: %w", err)
+	}
+
+	if err := s.userRepo.ResetLoginFailures(user.ID); err != nil {
+		return fmt.Errorf("Failed to unlock user: %w", err)
+	}
+
+	if err := s.securityEventRepo.Create(&entities.SecurityEvent{
+		UserID:  user.ID,
+		Type:    entities.SecurityEventAccountUnlocked,
+		Details: "Login lockout cleared by an administrator",
+	}); err != nil {
+		return fmt.Errorf("Failed to record security event: %w", err)
+	}
+
+	return nil
+}
+
+func (s *userService) CreateUser(adminID uint, req *dto.CreateUserRequest) (*dto.UserResponse, error) {
+	if req.Password == "" && !req.SendInvite {
+		return nil, errors.NewValidationError("Either a password or send_invite is required")
+	}
+
+	if _, err := s.userRepo.GetByEmail(req.Email); err == nil {
+		return nil, errors.NewValidationError("Email already exists")
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("Failed to get user: %w", err)
+	}
+
+	roles, err := s.getRoles(req.RoleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Invited users get a random password nobody knows until they choose
+	// their own through the invite link.
+	password := req.Password
+	if password == "" {
+		if password, err = security.GenerateOpaqueToken(); err != nil {
+			return nil, fmt.Errorf("Failed to generate password: %w", err)
+		}
+	}
+
+	hashedPassword, err := s.passwordManager.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to hash password: %w", err)
+	}
+
+	user := &entities.User{
+		Email:         req.Email,
+		Password:      hashedPassword,
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		Locale:        req.Locale,
+		IsActive:      true,
+		EmailVerified: req.EmailVerified,
+		Roles:         roles,
+	}
+	if req.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("Failed to create user: %w", err)
+	}
+
+	if err := s.recordEvent(user.ID, entities.SecurityEventAccountCreated, "Created by "+describeAdmin(adminID)); err != nil {
+		return nil, err
+	}
+
+	// The account exists at this point; failed mails can be resent through
+	// the forgot-password and resend-verification endpoints.
+	if req.SendInvite {
+		if err := s.passwordResetService.SendInvite(user); err != nil {
+			log.Printf("Failed to send invite to user %d: %v", user.ID, err)
+		}
+	} else if !user.EmailVerified {
+		if err := s.emailVerificationService.SendVerification(user); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
+	userResponse := s.authService.mapUserToResponse(user)
+	return &userResponse, nil
+}
+
+func (s *userService) DeactivateUser(adminID, userID uint, req *dto.UserStatusRequest) error {
+	if adminID == userID {
+		return errors.NewValidationError("You cannot deactivate your own account")
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return errors.NewValidationError("User is already deactivated")
+	}
+
+	user.IsActive = false
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("Failed to deactivate user: %w", err)
+	}
+
+	if err := s.sessionService.RevokeAllSessions(user.ID); err != nil {
+		return err
+	}
+
+	return s.recordEvent(user.ID, entities.SecurityEventAccountDisabled, fmt.Sprintf("Deactivated by %s: %s", describeAdmin(adminID), req.Reason))
+}
+
+func (s *userService) ReactivateUser(adminID, userID uint, req *dto.UserStatusRequest) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsActive {
+		return errors.NewValidationError("User is already active")
+	}
+
+	user.IsActive = true
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("Failed to reactivate user: %w", err)
+	}
+
+	return s.recordEvent(user.ID, entities.SecurityEventAccountEnabled, fmt.Sprintf("Reactivated by %s: %s", describeAdmin(adminID), req.Reason))
+}
+
+func (s *userService) DeleteUser(adminID, userID uint) error {
+	if adminID == userID {
+		return errors.NewValidationError("You cannot delete your own account")
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sessionService.RevokeAllSessions(user.ID); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return fmt.Errorf("Failed to delete user: %w", err)
+	}
+
+	return s.recordEvent(user.ID, entities.SecurityEventAccountDeleted, "Deleted by "+describeAdmin(adminID))
+}
+
+func (s *userService) RestoreUser(adminID, userID uint) error {
+	restored, err := s.userRepo.Restore(userID)
+	if err != nil {
+		return fmt.Errorf("Failed to restore user: %w", err)
+	}
+	if !restored {
+		return errors.NewNotFoundError("Deleted user not found")
+	}
+
+	return s.recordEvent(userID, entities.SecurityEventAccountRestored, "Restored by "+describeAdmin(adminID))
+}
+
+func (s *userService) PurgeUser(adminID, userID uint) error {
+	if adminID == userID {
+		return errors.NewValidationError("You cannot delete your own account")
+	}
+
+	purged, err := s.userRepo.Purge(userID)
+	if err != nil {
+		return fmt.Errorf("Failed to purge user: %w", err)
+	}
+	if !purged {
+		return errors.NewNotFoundError("User not found")
+	}
+
+	// The user's security events are gone with it, so leave a trace in the log.
+	log.Printf("User %d purged by %s", userID, describeAdmin(adminID))
+	return nil
+}
+
+func (s *userService) SetPassword(adminID, userID uint, password string) error {
+	if len(password) < 6 {
+		return errors.NewValidationError("Password must be at least 6 characters")
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.passwordManager.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("Failed to hash password: %w", err)
+	}
+
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("Failed to update password: %w", err)
+	}
+
+	if err := s.sessionService.RevokeAllSessions(user.ID); err != nil {
+		return err
+	}
+
+	return s.recordEvent(user.ID, entities.SecurityEventPasswordSet, "Password set by "+describeAdmin(adminID)+", all sessions revoked")
+}
+
+// describeAdmin names the administrator in security events. adminID is
+// zero for operators using authctl.
+func describeAdmin(adminID uint) string {
+	if adminID == 0 {
+		return "an operator"
+	}
+	return fmt.Sprintf("administrator %d", adminID)
+}
+
+func (s *userService) getUser(userID uint) (*entities.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("User not found")
+		}
+		return nil, fmt.Errorf("Failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// getRoles loads the given roles, or the default "user" role when none are
+// given.
+func (s *userService) getRoles(roleIDs []uint) ([]entities.Role, error) {
+	if len(roleIDs) == 0 {
+		role, err := s.roleRepo.GetByName("user")
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("Failed to get role: %w", err)
+		}
+		return []entities.Role{*role}, nil
+	}
+
+	roles := make([]entities.Role, 0, len(roleIDs))
+	for _, roleID := range roleIDs {
+		role, err := s.roleRepo.GetByID(roleID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, errors.NewValidationError(fmt.Sprintf("Role %d not found", roleID))
+			}
+			return nil, fmt.Errorf("Failed to get role: %w", err)
+		}
+		roles = append(roles, *role)
+	}
+	return roles, nil
+}
+
+func (s *userService) recordEvent(userID uint, eventType, details string) error {
+	if err := s.securityEventRepo.Create(&entities.SecurityEvent{
+		UserID:  userID,
+		Type:    eventType,
+		Details: details,
+	}); err != nil {
+		return fmt.Errorf("Failed to record security event: %w", err)
+	}
+	return nil
+}
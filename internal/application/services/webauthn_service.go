@@ -0,0 +1,415 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/domain/services"
+	"auth-system/internal/infrastructure/security"
+	"auth-system/pkg/errors"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type webAuthnService struct {
+	userRepo          repositories.UserRepository
+	credentialRepo    repositories.WebAuthnCredentialRepository
+	challengeRepo     repositories.WebAuthnChallengeRepository
+	securityEventRepo repositories.SecurityEventRepository
+	relyingParty      *security.WebAuthnRelyingParty
+	challengeTTL      time.Duration
+}
+
+func NewWebAuthnService(
+	userRepo repositories.UserRepository,
+	credentialRepo repositories.WebAuthnCredentialRepository,
+	challengeRepo repositories.WebAuthnChallengeRepository,
+	securityEventRepo repositories.SecurityEventRepository,
+	relyingParty *security.WebAuthnRelyingParty,
+	challengeTTL time.Duration,
+) services.WebAuthnService {
+	return &webAuthnService{
+		userRepo:          userRepo,
+		credentialRepo:    credentialRepo,
+		challengeRepo:     challengeRepo,
+		securityEventRepo: securityEventRepo,
+		relyingParty:      relyingParty,
+		challengeTTL:      challengeTTL,
+	}
+}
+
+func (s *webAuthnService) BeginRegistration(userID uint) (*dto.WebAuthnCreationOptions, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("User not found")
+		}
+		return nil, fmt.Errorf("Failed to get user: %w", err)
+	}
+
+	credentials, err := s.credentialRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get credentials: %w", err)
+	}
+
+	challenge, err := s.createChallenge(userID, entities.WebAuthnCeremonyRegistration, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.WebAuthnCreationOptions{
+		Challenge: challenge.Challenge,
+		RP: dto.WebAuthnRelyingPartyEntity{
+			ID:   s.relyingParty.ID,
+			Name: s.relyingParty.Name,
+		},
+		User: dto.WebAuthnUserEntity{
+			ID:          userHandle(user.ID),
+			Name:        user.Email,
+			DisplayName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		},
+		PubKeyCredParams: []dto.WebAuthnCredentialParameter{
+			{Type: "public-key", Alg: security.COSEAlgES256},
+			{Type: "public-key", Alg: security.COSEAlgEdDSA},
+			{Type: "public-key", Alg: security.COSEAlgRS256},
+		},
+		Timeout:            s.challengeTTL.Milliseconds(),
+		ExcludeCredentials: credentialDescriptors(credentials),
+		AuthenticatorSelection: dto.WebAuthnAuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+	}, nil
+}
+
+func (s *webAuthnService) FinishRegistration(userID uint, req *dto.WebAuthnRegistrationRequest) (*dto.WebAuthnCredentialResponse, error) {
+	clientDataJSON, err := security.DecodeWebAuthnBase64(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, errors.NewValidationError("Invalid client data")
+	}
+
+	attestationObject, err := security.DecodeWebAuthnBase64(req.Credential.Response.AttestationObject)
+	if err != nil {
+		return nil, errors.NewValidationError("Invalid attestation object")
+	}
+
+	challenge, err := s.consumeChallenge(clientDataJSON, entities.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID != userID {
+		return nil, errors.NewValidationError("Invalid or expired WebAuthn challenge")
+	}
+
+	registration, err := s.relyingParty.VerifyRegistration(clientDataJSON, attestationObject, challenge.Challenge, false)
+	if err != nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("Invalid WebAuthn registration: %v", err))
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(registration.CredentialID)
+	if _, err := s.credentialRepo.GetByCredentialID(credentialID); err == nil {
+		return nil, errors.NewValidationError("Credential is already registered")
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("Failed to get credential: %w", err)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "Passkey"
+	}
+
+	credential := &entities.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: credentialID,
+		PublicKey:    registration.PublicKey,
+		Algorithm:    registration.Algorithm,
+		SignCount:    registration.SignCount,
+		AAGUID:       formatAAGUID(registration.AAGUID),
+		Name:         name,
+		Transports:   strings.Join(req.Credential.Response.Transports, ","),
+	}
+
+	if err := s.credentialRepo.Create(credential); err != nil {
+		return nil, fmt.Errorf("Failed to store credential: %w", err)
+	}
+
+	if err := s.recordEvent(userID, entities.SecurityEventPasskeyAdded, fmt.Sprintf("Passkey %q registered", name)); err != nil {
+		return nil, err
+	}
+
+	response := mapWebAuthnCredentialToResponse(credential)
+	return &response, nil
+}
+
+func (s *webAuthnService) ListCredentials(userID uint) ([]dto.WebAuthnCredentialResponse, error) {
+	credentials, err := s.credentialRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get credentials: %w", err)
+	}
+
+	responses := make([]dto.WebAuthnCredentialResponse, len(credentials))
+	for i, credential := range credentials {
+		responses[i] = mapWebAuthnCredentialToResponse(credential)
+	}
+
+	return responses, nil
+}
+
+func (s *webAuthnService) DeleteCredential(userID, credentialID uint) error {
+	deleted, err := s.credentialRepo.Delete(userID, credentialID)
+	if err != nil {
+		return fmt.Errorf("Failed to delete credential: %w", err)
+	}
+	if !deleted {
+		return errors.NewNotFoundError("Credential not found")
+	}
+
+	return s.recordEvent(userID, entities.SecurityEventPasskeyRemoved, fmt.Sprintf("Passkey %d removed", credentialID))
+}
+
+func (s *webAuthnService) HasCredentials(userID uint) (bool, error) {
+	count, err := s.credentialRepo.CountByUserID(userID)
+	if err != nil {
+		return false, fmt.Errorf("Failed to count credentials: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (s *webAuthnService) BeginAuthentication(userID uint, requireUserVerification bool) (*dto.WebAuthnRequestOptions, error) {
+	allowCredentials := []dto.WebAuthnCredentialDescriptor{}
+	if userID != 0 {
+		credentials, err := s.credentialRepo.ListByUserID(userID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get credentials: %w", err)
+		}
+		if len(credentials) == 0 {
+			return nil, errors.NewValidationError("No WebAuthn credentials registered")
+		}
+		allowCredentials = credentialDescriptors(credentials)
+	}
+
+	challenge, err := s.createChallenge(userID, entities.WebAuthnCeremonyAuthentication, requireUserVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	userVerification := "preferred"
+	if requireUserVerification {
+		userVerification = "required"
+	}
+
+	return &dto.WebAuthnRequestOptions{
+		Challenge:        challenge.Challenge,
+		Timeout:          s.challengeTTL.Milliseconds(),
+		RPID:             s.relyingParty.ID,
+		AllowCredentials: allowCredentials,
+		UserVerification: userVerification,
+	}, nil
+}
+
+func (s *webAuthnService) FinishAuthentication(assertion *dto.WebAuthnAssertion, expectedUserID uint) (*entities.User, error) {
+	invalid := errors.NewUnauthorizedError("Invalid WebAuthn assertion")
+
+	clientDataJSON, err := security.DecodeWebAuthnBase64(assertion.Response.ClientDataJSON)
+	if err != nil {
+		return nil, invalid
+	}
+	authData, err := security.DecodeWebAuthnBase64(assertion.Response.AuthenticatorData)
+	if err != nil {
+		return nil, invalid
+	}
+	signature, err := security.DecodeWebAuthnBase64(assertion.Response.Signature)
+	if err != nil {
+		return nil, invalid
+	}
+	rawCredentialID, err := security.DecodeWebAuthnBase64(assertion.ID)
+	if err != nil {
+		return nil, invalid
+	}
+
+	challenge, err := s.consumeChallenge(clientDataJSON, entities.WebAuthnCeremonyAuthentication)
+	if err != nil {
+		return nil, err
+	}
+	// Challenges issued for a second factor are bound to that user and must
+	// not be redeemed for a passwordless login, and vice versa.
+	if challenge.UserID != expectedUserID {
+		return nil, invalid
+	}
+
+	credential, err := s.credentialRepo.GetByCredentialID(base64.RawURLEncoding.EncodeToString(rawCredentialID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, invalid
+		}
+		return nil, fmt.Errorf("Failed to get credential: %w", err)
+	}
+
+	if expectedUserID != 0 && credential.UserID != expectedUserID {
+		return nil, invalid
+	}
+
+	// From here on the assertion presents a credential of its owner, who is
+	// returned with the rejection so that it counts towards their lockout.
+	reject := func() (*entities.User, error) {
+		owner, err := s.userRepo.GetByID(credential.UserID)
+		if err != nil {
+			return nil, invalid
+		}
+		return owner, invalid
+	}
+
+	if assertion.Response.UserHandle != "" {
+		handle, err := security.DecodeWebAuthnBase64(assertion.Response.UserHandle)
+		if err != nil || string(handle) != strconv.FormatUint(uint64(credential.UserID), 10) {
+			return reject()
+		}
+	}
+
+	result, err := s.relyingParty.VerifyAssertion(
+		clientDataJSON,
+		authData,
+		signature,
+		challenge.Challenge,
+		credential.PublicKey,
+		challenge.RequireUserVerification,
+	)
+	if err != nil {
+		return reject()
+	}
+
+	// A counter that does not move forward means the credential may have
+	// been cloned. Authenticators without a counter always report zero.
+	counterOK := (result.SignCount == 0 && credential.SignCount == 0) || result.SignCount > credential.SignCount
+	if counterOK {
+		counterOK, err = s.credentialRepo.UpdateSignCount(credential.ID, result.SignCount, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("Failed to update credential: %w", err)
+		}
+	}
+	if !counterOK {
+		if err := s.recordEvent(credential.UserID, entities.SecurityEventPasskeyCloned, fmt.Sprintf(
+			"Passkey %d presented sign count %d, expected more than %d",
+			credential.ID, result.SignCount, credential.SignCount,
+		)); err != nil {
+			return nil, err
+		}
+		return reject()
+	}
+
+	user, err := s.userRepo.GetByID(credential.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, invalid
+		}
+		return nil, fmt.Errorf("Failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *webAuthnService) createChallenge(userID uint, ceremony string, requireUserVerification bool) (*entities.WebAuthnChallenge, error) {
+	value, err := security.NewWebAuthnChallenge()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate WebAuthn challenge: %w", err)
+	}
+
+	challenge := &entities.WebAuthnChallenge{
+		Challenge:               value,
+		UserID:                  userID,
+		Ceremony:                ceremony,
+		RequireUserVerification: requireUserVerification,
+		ExpiresAt:               time.Now().Add(s.challengeTTL),
+	}
+
+	if err := s.challengeRepo.Create(challenge); err != nil {
+		return nil, fmt.Errorf("Failed to store WebAuthn challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// consumeChallenge redeems the challenge echoed in the client data. The
+// relying party verifies the rest of the client data afterwards.
+func (s *webAuthnService) consumeChallenge(clientDataJSON []byte, ceremony string) (*entities.WebAuthnChallenge, error) {
+	invalid := errors.NewValidationError("Invalid or expired WebAuthn challenge")
+
+	clientData, err := security.ParseClientData(clientDataJSON)
+	if err != nil || clientData.Challenge == "" {
+		return nil, invalid
+	}
+
+	challenge, err := s.challengeRepo.Consume(clientData.Challenge)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, invalid
+		}
+		return nil, fmt.Errorf("Failed to consume WebAuthn challenge: %w", err)
+	}
+
+	if challenge.Ceremony != ceremony || time.Now().After(challenge.ExpiresAt) {
+		return nil, invalid
+	}
+
+	return challenge, nil
+}
+
+func (s *webAuthnService) recordEvent(userID uint, eventType, details string) error {
+	if err := s.securityEventRepo.Create(&entities.SecurityEvent{
+		UserID:  userID,
+		Type:    eventType,
+		Details: details,
+	}); err != nil {
+		return fmt.Errorf("Failed to record security event: %w", err)
+	}
+	return nil
+}
+
+// userHandle is the opaque WebAuthn user ID: the decimal user ID, base64url
+// encoded as required by the JSON serialization.
+func userHandle(userID uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(userID), 10)))
+}
+
+func credentialDescriptors(credentials []*entities.WebAuthnCredential) []dto.WebAuthnCredentialDescriptor {
+	descriptors := make([]dto.WebAuthnCredentialDescriptor, len(credentials))
+	for i, credential := range credentials {
+		descriptors[i] = dto.WebAuthnCredentialDescriptor{
+			Type:       "public-key",
+			ID:         credential.CredentialID,
+			Transports: splitTransports(credential.Transports),
+		}
+	}
+	return descriptors
+}
+
+func splitTransports(transports string) []string {
+	if transports == "" {
+		return nil
+	}
+	return strings.Split(transports, ",")
+}
+
+func formatAAGUID(aaguid []byte) string {
+	if len(aaguid) != 16 {
+		return ""
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", aaguid[0:4], aaguid[4:6], aaguid[6:8], aaguid[8:10], aaguid[10:16])
+}
+
+func mapWebAuthnCredentialToResponse(credential *entities.WebAuthnCredential) dto.WebAuthnCredentialResponse {
+	return dto.WebAuthnCredentialResponse{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		AAGUID:     credential.AAGUID,
+		Transports: splitTransports(credential.Transports),
+		CreatedAt:  credential.CreatedAt,
+		LastUsedAt: credential.LastUsedAt,
+	}
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"auth-system/internal/infrastructure/security"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+type fakeWebAuthnCredentialRepository struct {
+	repositories.WebAuthnCredentialRepository
+	credentials []*entities.WebAuthnCredential
+}
+
+func (r *fakeWebAuthnCredentialRepository) Create(credential *entities.WebAuthnCredential) error {
+	credential.ID = uint(len(r.credentials) + 1)
+	r.credentials = append(r.credentials, credential)
+	return nil
+}
+
+func (r *fakeWebAuthnCredentialRepository) GetByCredentialID(credentialID string) (*entities.WebAuthnCredential, error) {
+	for _, credential := range r.credentials {
+		if credential.CredentialID == credentialID {
+			stored := *credential
+			return &stored, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeWebAuthnCredentialRepository) ListByUserID(userID uint) ([]*entities.WebAuthnCredential, error) {
+	var credentials []*entities.WebAuthnCredential
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, credential)
+		}
+	}
+	return credentials, nil
+}
+
+func (r *fakeWebAuthnCredentialRepository) UpdateSignCount(id uint, signCount uint32, usedAt time.Time) (bool, error) {
+	for _, credential := range r.credentials {
+		if credential.ID == id {
+			if signCount > 0 && credential.SignCount >= signCount {
+				return false, nil
+			}
+			credential.SignCount = signCount
+			credential.LastUsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeWebAuthnChallengeRepository struct {
+	repositories.WebAuthnChallengeRepository
+	challenges map[string]*entities.WebAuthnChallenge
+}
+
+func (r *fakeWebAuthnChallengeRepository) Create(challenge *entities.WebAuthnChallenge) error {
+	r.challenges[challenge.Challenge] = challenge
+	return nil
+}
+
+func (r *fakeWebAuthnChallengeRepository) Consume(challenge string) (*entities.WebAuthnChallenge, error) {
+	stored, ok := r.challenges[challenge]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(r.challenges, challenge)
+	return stored, nil
+}
+
+// testAuthenticator is an in-memory ES256 authenticator holding one
+// credential.
+type testAuthenticator struct {
+	credentialID []byte
+	key          *ecdsa.PrivateKey
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testAuthenticator{credentialID: []byte("credential-1"), key: key}
+}
+
+// coseKey encodes the public key as a COSE_Key map
+// {1: 2, 3: -7, -1: 1, -2: x, -3: y}.
+func (a *testAuthenticator) coseKey() []byte {
+	key := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+	key = append(key, a.key.X.FillBytes(make([]byte, 32))...)
+	key = append(key, 0x22, 0x58, 0x20)
+	return append(key, a.key.Y.FillBytes(make([]byte, 32))...)
+}
+
+func (a *testAuthenticator) authData(signCount uint32, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append(rpIDHash[:], 0x01)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	if attested {
+		data[32] |= 0x40
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func (a *testAuthenticator) register(t *testing.T, challenge string) *dto.WebAuthnRegistrationRequest {
+	t.Helper()
+
+	// {"fmt": "none", "attStmt": {}, "authData": authData}
+	authData := a.authData(0, true)
+	attestation := []byte{0xa3, 0x63, 'f', 'm', 't', 0x64, 'n', 'o', 'n', 'e'}
+	attestation = append(attestation, 0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa0)
+	attestation = append(attestation, 0x68, 'a', 'u', 't', 'h', 'D', 'a', 't', 'a', 0x59)
+	attestation = binary.BigEndian.AppendUint16(attestation, uint16(len(authData)))
+	attestation = append(attestation, authData...)
+
+	return &dto.WebAuthnRegistrationRequest{
+		Name: "Test key",
+		Credential: dto.WebAuthnRegistrationCredential{
+			ID:   encodeBase64URL(a.credentialID),
+			Type: "public-key",
+			Response: dto.WebAuthnAttestationResponse{
+				ClientDataJSON:    encodeBase64URL(testClientData(t, "webauthn.create", challenge)),
+				AttestationObject: encodeBase64URL(attestation),
+			},
+		},
+	}
+}
+
+func (a *testAuthenticator) assert(t *testing.T, challenge string, signCount uint32) *dto.WebAuthnAssertion {
+	t.Helper()
+
+	clientDataJSON := testClientData(t, "webauthn.get", challenge)
+	authData := a.authData(signCount, false)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &dto.WebAuthnAssertion{
+		ID:   encodeBase64URL(a.credentialID),
+		Type: "public-key",
+		Response: dto.WebAuthnAssertionResponse{
+			ClientDataJSON:    encodeBase64URL(clientDataJSON),
+			AuthenticatorData: encodeBase64URL(authData),
+			Signature:         encodeBase64URL(signature),
+		},
+	}
+}
+
+func testClientData(t *testing.T, ceremony, challenge string) []byte {
+	t.Helper()
+	data, err := json.Marshal(security.CollectedClientData{Type: ceremony, Challenge: challenge, Origin: testOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func encodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+type webAuthnTest struct {
+	service       *webAuthnService
+	credentials   *fakeWebAuthnCredentialRepository
+	challenges    *fakeWebAuthnChallengeRepository
+	events        *fakeSecurityEventRepository
+	authenticator *testAuthenticator
+}
+
+// newWebAuthnTest returns a service where user 1 has registered the
+// authenticator.
+func newWebAuthnTest(t *testing.T) *webAuthnTest {
+	t.Helper()
+
+	w := &webAuthnTest{
+		credentials:   &fakeWebAuthnCredentialRepository{},
+		challenges:    &fakeWebAuthnChallengeRepository{challenges: make(map[string]*entities.WebAuthnChallenge)},
+		events:        &fakeSecurityEventRepository{},
+		authenticator: newTestAuthenticator(t),
+	}
+	w.service = NewWebAuthnService(
+		&fakeUserRepository{users: map[uint]*entities.User{1: {ID: 1, Email: "user@example.com"}}},
+		w.credentials,
+		w.challenges,
+		w.events,
+		security.NewWebAuthnRelyingParty(testRPID, "Example", []string{testOrigin}),
+		time.Minute,
+	).(*webAuthnService)
+
+	options, err := w.service.BeginRegistration(1)
+	if err != nil {
+		t.Fatalf("BeginRegistration error = %v", err)
+	}
+	if _, err := w.service.FinishRegistration(1, w.authenticator.register(t, options.Challenge)); err != nil {
+		t.Fatalf("FinishRegistration error = %v", err)
+	}
+	return w
+}
+
+func (w *webAuthnTest) login(t *testing.T, signCount uint32) (*entities.User, error) {
+	t.Helper()
+
+	options, err := w.service.BeginAuthentication(0, false)
+	if err != nil {
+		t.Fatalf("BeginAuthentication error = %v", err)
+	}
+	return w.service.FinishAuthentication(w.authenticator.assert(t, options.Challenge, signCount), 0)
+}
+
+func TestWebAuthnServiceCeremony(t *testing.T) {
+	w := newWebAuthnTest(t)
+
+	user, err := w.login(t, 1)
+	if err != nil {
+		t.Fatalf("FinishAuthentication error = %v", err)
+	}
+	if user.ID != 1 {
+		t.Errorf("FinishAuthentication user = %d, want 1", user.ID)
+	}
+	if got := w.credentials.credentials[0].SignCount; got != 1 {
+		t.Errorf("stored sign count = %d, want 1", got)
+	}
+}
+
+func TestWebAuthnServiceRejectsReplayedChallenge(t *testing.T) {
+	w := newWebAuthnTest(t)
+
+	options, err := w.service.BeginAuthentication(0, false)
+	if err != nil {
+		t.Fatalf("BeginAuthentication error = %v", err)
+	}
+	assertion := w.authenticator.assert(t, options.Challenge, 1)
+	if _, err := w.service.FinishAuthentication(assertion, 0); err != nil {
+		t.Fatalf("FinishAuthentication error = %v", err)
+	}
+
+	if _, err := w.service.FinishAuthentication(assertion, 0); err == nil {
+		t.Error("replayed assertion was accepted")
+	}
+	// Nor can the challenge be answered anew with a higher counter.
+	if _, err := w.service.FinishAuthentication(w.authenticator.assert(t, options.Challenge, 2), 0); err == nil {
+		t.Error("replayed challenge was accepted")
+	}
+}
+
+func TestWebAuthnServiceRejectsForeignChallenges(t *testing.T) {
+	w := newWebAuthnTest(t)
+
+	registration, err := w.service.BeginRegistration(1)
+	if err != nil {
+		t.Fatalf("BeginRegistration error = %v", err)
+	}
+	if _, err := w.service.FinishAuthentication(w.authenticator.assert(t, registration.Challenge, 1), 0); err == nil {
+		t.Error("registration challenge was accepted for authentication")
+	}
+
+	secondFactor, err := w.service.BeginAuthentication(1, false)
+	if err != nil {
+		t.Fatalf("BeginAuthentication error = %v", err)
+	}
+	if _, err := w.service.FinishAuthentication(w.authenticator.assert(t, secondFactor.Challenge, 1), 0); err == nil {
+		t.Error("second factor challenge was accepted for a passwordless login")
+	}
+
+	expired, err := w.service.BeginAuthentication(0, false)
+	if err != nil {
+		t.Fatalf("BeginAuthentication error = %v", err)
+	}
+	w.challenges.challenges[expired.Challenge].ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := w.service.FinishAuthentication(w.authenticator.assert(t, expired.Challenge, 1), 0); err == nil {
+		t.Error("expired challenge was accepted")
+	}
+}
+
+func TestWebAuthnServiceSignCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous uint32
+		next     uint32
+		wantOK   bool
+	}{
+		{"increase", 5, 6, true},
+		{"no counter", 0, 0, true},
+		{"first use of a counter", 0, 1, true},
+		{"repeated", 5, 5, false},
+		{"regression", 5, 3, false},
+		{"reset to zero", 5, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWebAuthnTest(t)
+			if tt.previous > 0 {
+				if _, err := w.login(t, tt.previous); err != nil {
+					t.Fatalf("FinishAuthentication error = %v", err)
+				}
+			}
+			events := len(w.events.events)
+
+			_, err := w.login(t, tt.next)
+			if ok := err == nil; ok != tt.wantOK {
+				t.Fatalf("FinishAuthentication error = %v, want success %v", err, tt.wantOK)
+			}
+
+			cloned := len(w.events.events) > events &&
+				w.events.events[len(w.events.events)-1].Type == entities.SecurityEventPasskeyCloned
+			if cloned == tt.wantOK {
+				t.Errorf("recorded passkey_sign_count_mismatch = %v, want %v", cloned, !tt.wantOK)
+			}
+			if !tt.wantOK && w.credentials.credentials[0].SignCount != tt.previous {
+				t.Errorf("stored sign count = %d, want %d", w.credentials.credentials[0].SignCount, tt.previous)
+			}
+		})
+	}
+}
+
+func TestWebAuthnServiceReturnsOwnerOfRejectedAssertion(t *testing.T) {
+	w := newWebAuthnTest(t)
+
+	options, err := w.service.BeginAuthentication(0, false)
+	if err != nil {
+		t.Fatalf("BeginAuthentication error = %v", err)
+	}
+	assertion := w.authenticator.assert(t, options.Challenge, 1)
+	assertion.Response.Signature = encodeBase64URL([]byte("not a signature"))
+
+	owner, err := w.service.FinishAuthentication(assertion, 0)
+	if err == nil {
+		t.Fatal("assertion with a bad signature was accepted")
+	}
+	if owner == nil || owner.ID != 1 {
+		t.Errorf("FinishAuthentication owner = %v, want user 1", owner)
+	}
+}
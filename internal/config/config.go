@@ -3,14 +3,24 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Server   ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Security  SecurityConfig
+	MFA       MFAConfig
+	WebAuthn  WebAuthnConfig
+	Mail      MailConfig
+	Account   AccountConfig
+	Lockout   LockoutConfig
+	RateLimit RateLimitConfig
+	Bootstrap BootstrapConfig
+	RBAC      RBACConfig
+	Server    ServerConfig
 }
 
 type DatabaseConfig struct {
@@ -20,16 +30,117 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// AutoMigrate applies pending migrations at startup. When disabled the
+	// server refuses to start until they are applied with authctl.
+	AutoMigrate bool
 }
 
 type JWTConfig struct {
-	Secret          string
-	AccessTokenTTL  string
-	RefreshTokenTTL string
+	Secret                  string
+	Algorithm               string // derived from the key when empty
+	PrivateKeyPath          string
+	KeyID                   string
+	PreviousSecrets         string // comma-separated, verification only
+	RotationAlgorithm       string
+	KeyRotationInterval     string
+	KeySyncInterval         string
+	AccessTokenTTL          string
+	RefreshTokenTTL         string
+	RevocationStore         string // "postgres" or "memory"
+	RevocationPruneInterval string
+}
+
+type SecurityConfig struct {
+	// EncryptionSecret protects secrets stored in the database, such as
+	// managed signing keys and TOTP secrets. Falls back to JWT.Secret.
+	EncryptionSecret string
+}
+
+type MFAConfig struct {
+	Issuer       string
+	ChallengeTTL string
+}
+
+type WebAuthnConfig struct {
+	RPID         string
+	RPName       string
+	Origins      string // comma-separated
+	ChallengeTTL string
+}
+
+type MailConfig struct {
+	Driver        string // "smtp", "file" or "log"
+	From          string
+	DefaultLocale string
+	TemplatesDir  string // overrides the built-in templates when set
+	FileDir       string
+	SMTPHost      string
+	SMTPPort      string
+	SMTPUsername  string
+	SMTPPassword  string
+	SMTPSecurity  string // "starttls", "tls" or "none"
+	QueueSize     int    // 0 sends synchronously
+	QueueWorkers  int
+	MaxAttempts   int
+	RetryBackoff  string
+}
+
+type AccountConfig struct {
+	// RequireEmailVerification blocks login until the email is verified.
+	RequireEmailVerification bool
+	EmailVerificationTTL     string
+	// EmailVerificationURL is the page linked from the verification email;
+	// the token is appended as the "token" query parameter.
+	EmailVerificationURL string
+	PasswordResetTTL     string
+	PasswordResetURL     string
+	// InviteURL is where invited users choose their first password; the
+	// link works like a password reset link.
+	InviteTTL string
+	InviteURL string
+}
+
+type LockoutConfig struct {
+	AccountThreshold int // 0 disables account lockout
+	IPThreshold      int // 0 disables IP blocking
+	BaseDuration     string
+	MaxDuration      string
+	ResetAfter       string
+}
+
+// RateLimitConfig limits are "<requests>/<period>", e.g. "5/1m"; "0"
+// disables a limit.
+type RateLimitConfig struct {
+	Store        string // "postgres" or "memory"
+	Login        string
+	LoginEmail   string
+	Register     string
+	Refresh      string
+	MFA          string
+	Email        string
+	EmailAddress string
+	User         string
+}
+
+// BootstrapConfig names the first administrator. It is applied once, while
+// no active user has the admin role.
+type BootstrapConfig struct {
+	AdminEmail    string
+	AdminPassword string // password of the new account, or of the existing one
+}
+
+type RBACConfig struct {
+	ManifestPath string // YAML or JSON manifest applied at startup
 }
 
 type ServerConfig struct {
 	Port string
+	// TrustedProxies are the proxy IPs and CIDRs whose X-Forwarded-For
+	// and X-Real-IP headers are believed; comma-separated, none by default.
+	TrustedProxies string
+	// ShutdownTimeout bounds the wait for requests in flight and queued
+	// mail when the server stops.
+	ShutdownTimeout string
 }
 
 func Load() *Config {
@@ -45,14 +156,90 @@ func Load() *Config {
 			Password: getEnv("DB_PASSWORD", ""),
 			DBName:   getEnv("DB_NAME", "auth_db"),
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+
+			AutoMigrate: getEnvBool("DB_AUTO_MIGRATE", true),
 		},
 		JWT: JWTConfig{
-			Secret:          getEnv("JWT_SECRET", "your-secret-key-change-this"),
-			AccessTokenTTL:  getEnv("JWT_ACCESS_TTL", "15m"),
-			RefreshTokenTTL: getEnv("JWT_REFRESH_TTL", "7d"),
+			Secret:                  getEnv("JWT_SECRET", "your-secret-key-change-this"),
+			Algorithm:               getEnv("JWT_ALGORITHM", ""),
+			PrivateKeyPath:          getEnv("JWT_PRIVATE_KEY_PATH", ""),
+			KeyID:                   getEnv("JWT_KEY_ID", ""),
+			PreviousSecrets:         getEnv("JWT_PREVIOUS_SECRETS", ""),
+			RotationAlgorithm:       getEnv("JWT_ROTATION_ALGORITHM", ""),
+			KeyRotationInterval:     getEnv("JWT_KEY_ROTATION_INTERVAL", "0"),
+			KeySyncInterval:         getEnv("JWT_KEY_SYNC_INTERVAL", "1m"),
+			AccessTokenTTL:          getEnv("JWT_ACCESS_TTL", "15m"),
+			RefreshTokenTTL:         getEnv("JWT_REFRESH_TTL", "7d"),
+			RevocationStore:         getEnv("JWT_REVOCATION_STORE", "postgres"),
+			RevocationPruneInterval: getEnv("JWT_REVOCATION_PRUNE_INTERVAL", "1h"),
+		},
+		Security: SecurityConfig{
+			EncryptionSecret: getEnv("ENCRYPTION_SECRET", ""),
+		},
+		MFA: MFAConfig{
+			Issuer:       getEnv("MFA_ISSUER", "AuthSystem"),
+			ChallengeTTL: getEnv("MFA_CHALLENGE_TTL", "5m"),
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:         getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPName:       getEnv("WEBAUTHN_RP_NAME", "AuthSystem"),
+			Origins:      getEnv("WEBAUTHN_ORIGINS", "http://localhost:8080"),
+			ChallengeTTL: getEnv("WEBAUTHN_CHALLENGE_TTL", "5m"),
+		},
+		Mail: MailConfig{
+			Driver:        getEnv("MAIL_DRIVER", "log"),
+			From:          getEnv("MAIL_FROM", "AuthSystem <no-reply@localhost>"),
+			DefaultLocale: getEnv("MAIL_DEFAULT_LOCALE", "en"),
+			TemplatesDir:  getEnv("MAIL_TEMPLATES_DIR", ""),
+			FileDir:       getEnv("MAIL_FILE_DIR", "./mail"),
+			SMTPHost:      getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:      getEnv("SMTP_PORT", "587"),
+			SMTPUsername:  getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
+			SMTPSecurity:  getEnv("SMTP_SECURITY", "starttls"),
+			QueueSize:     getEnvInt("MAIL_QUEUE_SIZE", 100),
+			QueueWorkers:  getEnvInt("MAIL_QUEUE_WORKERS", 2),
+			MaxAttempts:   getEnvInt("MAIL_MAX_ATTEMPTS", 5),
+			RetryBackoff:  getEnv("MAIL_RETRY_BACKOFF", "5s"),
+		},
+		Account: AccountConfig{
+			RequireEmailVerification: getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+			EmailVerificationTTL:     getEnv("EMAIL_VERIFICATION_TTL", "24h"),
+			EmailVerificationURL:     getEnv("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email"),
+			PasswordResetTTL:         getEnv("PASSWORD_RESET_TTL", "1h"),
+			PasswordResetURL:         getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+			InviteTTL:                getEnv("INVITE_TTL", "72h"),
+			InviteURL:                getEnv("INVITE_URL", "http://localhost:3000/accept-invite"),
+		},
+		Lockout: LockoutConfig{
+			AccountThreshold: getEnvInt("LOCKOUT_ACCOUNT_THRESHOLD", 5),
+			IPThreshold:      getEnvInt("LOCKOUT_IP_THRESHOLD", 20),
+			BaseDuration:     getEnv("LOCKOUT_BASE_DURATION", "1m"),
+			MaxDuration:      getEnv("LOCKOUT_MAX_DURATION", "1h"),
+			ResetAfter:       getEnv("LOCKOUT_RESET_AFTER", "24h"),
+		},
+		RateLimit: RateLimitConfig{
+			Store:        getEnv("RATE_LIMIT_STORE", "memory"),
+			Login:        getEnv("RATE_LIMIT_LOGIN", "20/1m"),
+			LoginEmail:   getEnv("RATE_LIMIT_LOGIN_EMAIL", "5/1m"),
+			Register:     getEnv("RATE_LIMIT_REGISTER", "10/1h"),
+			Refresh:      getEnv("RATE_LIMIT_REFRESH", "60/1m"),
+			MFA:          getEnv("RATE_LIMIT_MFA", "10/1m"),
+			Email:        getEnv("RATE_LIMIT_EMAIL", "10/15m"),
+			EmailAddress: getEnv("RATE_LIMIT_EMAIL_ADDRESS", "3/15m"),
+			User:         getEnv("RATE_LIMIT_USER", "300/1m"),
+		},
+		Bootstrap: BootstrapConfig{
+			AdminEmail:    getEnv("ADMIN_EMAIL", ""),
+			AdminPassword: getEnv("ADMIN_PASSWORD", ""),
+		},
+		RBAC: RBACConfig{
+			ManifestPath: getEnv("RBAC_MANIFEST", ""),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:            getEnv("SERVER_PORT", "8080"),
+			TrustedProxies:  getEnv("TRUSTED_PROXIES", ""),
+			ShutdownTimeout: getEnv("SERVER_SHUTDOWN_TIMEOUT", "30s"),
 		},
 	}
 }
@@ -63,3 +250,19 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// IPLoginFailure counts failed logins from one IP address across all
+// accounts, so a single client cannot spray passwords at many users.
+type IPLoginFailure struct {
+	IPAddress      string     `gorm:"primaryKey;size:45" json:"ip_address"`
+	FailedAttempts int        `gorm:"not null" json:"failed_attempts"`
+	LastFailedAt   time.Time  `gorm:"index;not null" json:"last_failed_at"`
+	BlockedUntil   *time.Time `json:"blocked_until"`
+}
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+// PasswordResetToken is a single-use token mailed to a user who forgot
+// their password. Only the SHA-256 digest of the token is stored.
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
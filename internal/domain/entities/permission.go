@@ -12,3 +12,20 @@ type Permission struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+// Permission grant effects. A deny overrides every allow.
+const (
+	PermissionEffectAllow = "allow"
+	PermissionEffectDeny  = "deny"
+)
+
+// PermissionGrant is a permission as it applies to a user: granted or denied
+// by one of the user's roles, or denied to the user directly. It is not
+// stored as such.
+type PermissionGrant struct {
+	Permission Permission `gorm:"embedded"`
+	Effect     string
+	// Role is the role carrying the grant, possibly inherited; empty for a
+	// denial made to the user directly.
+	Role string
+}
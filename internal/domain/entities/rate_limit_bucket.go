@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+// RateLimitBucket is the token bucket state of one rate limit key, shared by
+// all instances when rate limits are stored in Postgres.
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey;size:255" json:"key"`
+	Tokens    float64   `gorm:"not null" json:"tokens"`
+	UpdatedAt time.Time `gorm:"index;not null" json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// RecoveryCode is a single-use MFA backup code, stored as a bcrypt hash.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package entities
+
+import "time"
+
+// RefreshToken records an issued refresh token. Tokens obtained from the same
+// login share a FamilyID; every refresh consumes the presented token and
+// issues a new one in the same family.
+type RefreshToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	JTI        string     `gorm:"uniqueIndex;not null" json:"jti"`
+	FamilyID   string     `gorm:"index;not null" json:"family_id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	ExpiresAt  time.Time  `gorm:"index;not null" json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
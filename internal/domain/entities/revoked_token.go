@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	JTI       string    `gorm:"uniqueIndex;not null" json:"jti"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -7,7 +7,12 @@ type Role struct {
 	Name        string       `gorm:"unique;not null" json:"name"`
 	Description string       `json:"description"`
 	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions"`
-	Users       []User       `gorm:"many2many:user_roles;" json:"-"`
-	CreatedAt   time.Time    `json:"created_at"`
-	UpdatedAt   time.Time    `json:"updated_at"`
+	// DeniedPermissions override any grant, including those of other roles.
+	DeniedPermissions []Permission `gorm:"many2many:role_denied_permissions;" json:"denied_permissions"`
+	// Parents are the roles whose permissions and denials this role
+	// inherits.
+	Parents   []Role    `gorm:"many2many:role_parents;joinForeignKey:RoleID;joinReferences:ParentID" json:"parents"`
+	Users     []User    `gorm:"many2many:user_roles;" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
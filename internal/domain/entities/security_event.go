@@ -0,0 +1,31 @@
+package entities
+
+import "time"
+
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+	SecurityEventMFAEnabled        = "mfa_enabled"
+	SecurityEventMFADisabled       = "mfa_disabled"
+	SecurityEventRecoveryCodeUsed  = "recovery_code_used"
+	SecurityEventRecoveryCodesNew  = "recovery_codes_regenerated"
+	SecurityEventPasskeyAdded      = "passkey_added"
+	SecurityEventPasskeyRemoved    = "passkey_removed"
+	SecurityEventPasskeyCloned     = "passkey_sign_count_mismatch"
+	SecurityEventPasswordReset     = "password_reset"
+	SecurityEventPasswordSet       = "password_set"
+	SecurityEventAccountLocked     = "account_locked"
+	SecurityEventAccountUnlocked   = "account_unlocked"
+	SecurityEventAccountCreated    = "account_created"
+	SecurityEventAccountDisabled   = "account_deactivated"
+	SecurityEventAccountEnabled    = "account_reactivated"
+	SecurityEventAccountDeleted    = "account_deleted"
+	SecurityEventAccountRestored   = "account_restored"
+)
+
+type SecurityEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Type      string    `gorm:"index;not null" json:"type"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// Session is the server-side record of a login. Its refresh tokens form the
+// token family identified by FamilyID.
+type Session struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID   string     `gorm:"uniqueIndex;not null" json:"-"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `gorm:"index;not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+const (
+	SigningKeyStatusActive  = "active"
+	SigningKeyStatusRetired = "retired"
+)
+
+// SigningKey is a token signing key managed by rotation. Exactly one key is
+// active; retired keys remain valid for verification until VerifyUntil.
+type SigningKey struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	KID         string     `gorm:"uniqueIndex;not null" json:"kid"`
+	Algorithm   string     `gorm:"not null" json:"algorithm"`
+	PrivateKey  string     `gorm:"type:text;not null" json:"-"` // encrypted PEM
+	Status      string     `gorm:"index;not null" json:"status"`
+	ActivatedAt time.Time  `json:"activated_at"`
+	RetiredAt   *time.Time `json:"retired_at"`
+	VerifyUntil *time.Time `gorm:"index" json:"verify_until"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
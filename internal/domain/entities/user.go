@@ -7,14 +7,39 @@ import (
 )
 
 type User struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
+	ID        uint           `gorm:"primaryKey;index:idx_users_created_at_id,priority:2" json:"id"`
 	Email     string         `gorm:"unique;not null" json:"email"`
 	Password  string         `gorm:"not null" json:"-"`
 	FirstName string         `gorm:"not null" json:"first_name"`
 	LastName  string         `gorm:"not null" json:"last_name"`
 	IsActive  bool           `gorm:"default:true" json:"is_active"`
 	Roles     []Role         `gorm:"many2many:user_roles;" json:"roles"`
-	CreatedAt time.Time      `json:"created_at"`
+	CreatedAt time.Time      `gorm:"index:idx_users_created_at_id,priority:1" json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Locale selects the language of emails, e.g. "en" or "vi".
+	Locale string `gorm:"size:16" json:"locale"`
+
+	EmailVerified   bool       `gorm:"default:false" json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// TOTP secrets are stored encrypted. MFAPendingSecret holds a secret
+	// being enrolled until the user confirms it with a first code.
+	MFAEnabled       bool   `gorm:"default:false" json:"mfa_enabled"`
+	MFASecret        string `json:"-"`
+	MFAPendingSecret string `json:"-"`
+	MFALastUsedStep  int64  `json:"-"`
+
+	// Login failure tracking, written only through the dedicated
+	// UserRepository methods so concurrent logins do not lose updates.
+	FailedLoginAttempts int        `gorm:"default:0" json:"-"`
+	LastFailedLoginAt   *time.Time `json:"-"`
+	LockedUntil         *time.Time `json:"locked_until"`
+
+	// RecoveryCodes is preloaded with the unused codes only.
+	RecoveryCodes []RecoveryCode `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
+
+	// DeniedPermissions override the grants of the user's roles.
+	DeniedPermissions []Permission `gorm:"many2many:user_denied_permissions;" json:"-"`
 }
@@ -0,0 +1,37 @@
+package entities
+
+import "time"
+
+// WebAuthnCredential is a registered passkey or security key. CredentialID
+// is stored base64url-encoded; PublicKey holds the raw COSE key.
+type WebAuthnCredential struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"index;not null" json:"user_id"`
+	CredentialID string     `gorm:"uniqueIndex;not null" json:"credential_id"`
+	PublicKey    []byte     `gorm:"not null" json:"-"`
+	Algorithm    int        `gorm:"not null" json:"algorithm"`
+	SignCount    uint32     `json:"sign_count"`
+	AAGUID       string     `json:"aaguid"`
+	Name         string     `json:"name"`
+	Transports   string     `json:"transports"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+const (
+	WebAuthnCeremonyRegistration   = "registration"
+	WebAuthnCeremonyAuthentication = "authentication"
+)
+
+// WebAuthnChallenge is a pending ceremony. UserID is zero for discoverable
+// (username-less) logins.
+type WebAuthnChallenge struct {
+	ID                      uint      `gorm:"primaryKey" json:"id"`
+	Challenge               string    `gorm:"uniqueIndex;not null" json:"-"`
+	UserID                  uint      `gorm:"index" json:"user_id"`
+	Ceremony                string    `gorm:"not null" json:"ceremony"`
+	RequireUserVerification bool      `json:"require_user_verification"`
+	ExpiresAt               time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt               time.Time `json:"created_at"`
+}
@@ -1,14 +1,73 @@
 package repositories
 
-import "auth-system/internal/domain/entities"
+import (
+	"auth-system/internal/domain/entities"
+	"time"
+)
+
+// UserFilter narrows and orders user listings. Zero fields do not filter.
+type UserFilter struct {
+	// Search matches email, first name, last name or full name, ignoring case.
+	Search        string
+	Role          string
+	IsActive      *bool
+	CreatedFrom   *time.Time
+	CreatedBefore *time.Time
+	// Deleted lists soft-deleted users instead of live ones.
+	Deleted bool
+	// SortBy is "created_at", "email", "first_name", "last_name" or "id";
+	// ties are broken by ID.
+	SortBy   string
+	SortDesc bool
+}
+
+// UserCursor marks a row of a keyset listing by its sort key and ID. Only
+// the field of the listing's sort order is used.
+type UserCursor struct {
+	CreatedAt time.Time
+	Email     string
+	ID        uint
+}
 
 type UserRepository interface {
 	Create(user *entities.User) error
 	GetByID(id uint) (*entities.User, error)
 	GetByEmail(email string) (*entities.User, error)
 	Update(user *entities.User) error
+	// Delete soft-deletes the user.
 	Delete(id uint) error
-	List(offset, limit int) ([]*entities.User, error)
+	// Restore undoes Delete and reports false if no deleted user has id.
+	Restore(id uint) (bool, error)
+	// Purge permanently removes the user, deleted or not, with everything
+	// that belongs to it. It reports false if no such user exists.
+	Purge(id uint) (bool, error)
+	// List returns a page of the users matching filter and their total count.
+	List(filter UserFilter, offset, limit int) ([]*entities.User, int64, error)
+	// ListPage returns up to limit users after cursor in filter order, or
+	// before it when backward is set, without OFFSET so deep pages stay
+	// cheap. A nil cursor starts at the first or, backward, the last row.
+	// Only the "created_at", "email" and "id" sort orders are supported.
+	ListPage(filter UserFilter, cursor *UserCursor, backward bool, limit int) ([]*entities.User, error)
+	// RecordLoginFailure increments the failed login counter, restarting it
+	// when the previous failure is older than resetBefore, and returns it.
+	RecordLoginFailure(id uint, now, resetBefore time.Time) (int, error)
+	Lock(id uint, until time.Time) error
+	// UseMFAStep records step as the last TOTP time step used by the user
+	// if it is later than the recorded one, in a single conditional update,
+	// and reports whether it was; false means the code is replayed.
+	UseMFAStep(id uint, step int64) (bool, error)
+	// ResetLoginFailures clears the failed login counter and any lock.
+	ResetLoginFailures(id uint) error
+	// GetDeniedPermissions returns the permissions denied to the user
+	// directly, by name.
+	GetDeniedPermissions(userID uint) ([]entities.Permission, error)
+	AddDeniedPermissions(userID uint, permissions []entities.Permission) error
+	RemoveDeniedPermission(userID, permissionID uint) error
+	// AddRole and RemoveRole change the user's roles; Update saves roles
+	// too but leaves cached permission policies as they are, so it is only
+	// for users that cannot have one yet.
+	AddRole(userID, roleID uint) error
+	RemoveRole(userID, roleID uint) error
 }
 
 type RoleRepository interface {
@@ -16,8 +75,22 @@ type RoleRepository interface {
 	GetByID(id uint) (*entities.Role, error)
 	GetByName(name string) (*entities.Role, error)
 	Update(role *entities.Role) error
+	// Delete removes the role together with its user, permission, denial
+	// and parent links.
 	Delete(id uint) error
 	List() ([]*entities.Role, error)
+	CountUsers(roleID uint) (int64, error)
+	AddPermissions(roleID uint, permissions []entities.Permission) error
+	RemovePermission(roleID, permissionID uint) error
+	AddDeniedPermissions(roleID uint, permissions []entities.Permission) error
+	RemoveDeniedPermission(roleID, permissionID uint) error
+	// SetParents replaces the roles the role inherits from.
+	SetParents(roleID uint, parents []entities.Role) error
+	// Reconcile upserts the permissions, then creates or updates the roles
+	// by name and sets the permissions, denials and parents of each to
+	// exactly those named in role.Permissions, role.DeniedPermissions and
+	// role.Parents, all in one transaction.
+	Reconcile(permissions []*entities.Permission, roles []*entities.Role) error
 }
 
 type PermissionRepository interface {
@@ -25,7 +98,119 @@ type PermissionRepository interface {
 	GetByID(id uint) (*entities.Permission, error)
 	GetByName(name string) (*entities.Permission, error)
 	Update(permission *entities.Permission) error
+	// Delete removes the permission and its grants and denials.
 	Delete(id uint) error
 	List() ([]*entities.Permission, error)
+	// GetByUserID returns the permissions granted to the user's roles and
+	// the roles they inherit from.
 	GetByUserID(userID uint) ([]*entities.Permission, error)
+	// GetGrantsByUserID returns everything that decides the user's
+	// permissions: the grants and denials of the user's roles and the
+	// roles they inherit from, and the denials made to the user directly.
+	GetGrantsByUserID(userID uint) ([]*entities.PermissionGrant, error)
+	// CountRoles counts the roles granting or denying the permission.
+	CountRoles(permissionID uint) (int64, error)
+	CountUserDenials(permissionID uint) (int64, error)
+	// Upsert creates the permissions or updates the description of those
+	// that already exist by name, filling in their IDs.
+	Upsert(permissions []*entities.Permission) error
+	// Version returns the RBAC version, which every change to roles,
+	// grants, denials, role parents or user roles increments, so that
+	// cached permission policies can be checked for staleness.
+	Version() (int64, error)
+}
+
+type RevokedTokenRepository interface {
+	Revoke(token *entities.RevokedToken) error
+	IsRevoked(jti string) (bool, error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type RefreshTokenRepository interface {
+	Create(token *entities.RefreshToken) error
+	GetByJTI(jti string) (*entities.RefreshToken, error)
+	// MarkConsumed reports false if the token was already consumed or revoked.
+	MarkConsumed(jti string) (bool, error)
+	RevokeFamily(familyID string) error
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type SecurityEventRepository interface {
+	Create(event *entities.SecurityEvent) error
+	ListByUserID(userID uint, limit int) ([]*entities.SecurityEvent, error)
+}
+
+type SessionRepository interface {
+	Create(session *entities.Session) error
+	GetByID(id uint) (*entities.Session, error)
+	ListActiveByUserID(userID uint) ([]*entities.Session, error)
+	Update(session *entities.Session) error
+	Revoke(id uint) error
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type SigningKeyRepository interface {
+	GetActive() (*entities.SigningKey, error)
+	// ListUsable returns the active key and retired keys still valid at now.
+	ListUsable(now time.Time) ([]*entities.SigningKey, error)
+	// Rotate activates newKey and retires the current active key with the
+	// given verification deadline. It does nothing and returns false when
+	// the active key was activated at or after activatedBefore, so concurrent
+	// instances do not rotate twice.
+	Rotate(newKey *entities.SigningKey, verifyUntil, activatedBefore time.Time) (bool, error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type RecoveryCodeRepository interface {
+	// ReplaceForUser deletes all existing codes of the user and stores codes.
+	ReplaceForUser(userID uint, codes []*entities.RecoveryCode) error
+	ListUnusedByUserID(userID uint) ([]*entities.RecoveryCode, error)
+	// MarkUsed reports false if the code had already been used.
+	MarkUsed(id uint) (bool, error)
+	DeleteByUserID(userID uint) error
+}
+
+type WebAuthnCredentialRepository interface {
+	Create(credential *entities.WebAuthnCredential) error
+	GetByCredentialID(credentialID string) (*entities.WebAuthnCredential, error)
+	ListByUserID(userID uint) ([]*entities.WebAuthnCredential, error)
+	CountByUserID(userID uint) (int64, error)
+	// UpdateSignCount stores the new counter and reports false if another
+	// assertion already advanced it to signCount or beyond.
+	UpdateSignCount(id uint, signCount uint32, usedAt time.Time) (bool, error)
+	Delete(userID, id uint) (bool, error)
+}
+
+type WebAuthnChallengeRepository interface {
+	Create(challenge *entities.WebAuthnChallenge) error
+	// Consume deletes and returns the challenge, so it can only be used once.
+	Consume(challenge string) (*entities.WebAuthnChallenge, error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type PasswordResetTokenRepository interface {
+	Create(token *entities.PasswordResetToken) error
+	GetByTokenHash(tokenHash string) (*entities.PasswordResetToken, error)
+	// MarkUsed reports false if the token had already been used.
+	MarkUsed(id uint) (bool, error)
+	DeleteByUserID(userID uint) error
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type IPLoginFailureRepository interface {
+	Get(ipAddress string) (*entities.IPLoginFailure, error)
+	// RecordFailure works like UserRepository.RecordLoginFailure for an IP.
+	RecordFailure(ipAddress string, now, resetBefore time.Time) (int, error)
+	Block(ipAddress string, until time.Time) error
+	DeleteStale(before time.Time) (int64, error)
+}
+
+type RateLimitRepository interface {
+	// Take removes one token from the bucket of key, refilled at rate tokens
+	// per second up to burst. When the bucket is empty it returns false and
+	// how long until a token is available.
+	Take(key string, rate float64, burst int, now time.Time) (bool, time.Duration, error)
+	// DeleteStale removes buckets untouched since before. Those are full
+	// again as long as before is at least burst/rate ago.
+	DeleteStale(before time.Time) (int64, error)
 }
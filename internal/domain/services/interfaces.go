@@ -8,19 +8,166 @@ import (
 type AuthService interface {
 	Login(req *dto.LoginRequest) (*dto.AuthResponse, error)
 	Register(req *dto.RegisterRequest) (*dto.AuthResponse, error)
-	RefreshToken(refreshToken string) (*dto.AuthResponse, error)
-	Logout(userID uint) error
+	VerifyMFA(req *dto.MFAVerifyRequest) (*dto.AuthResponse, error)
+	// BeginMFAWebAuthn issues assertion options for a pending MFA challenge.
+	BeginMFAWebAuthn(req *dto.MFAWebAuthnBeginRequest) (*dto.WebAuthnRequestOptions, error)
+	BeginPasskeyLogin() (*dto.WebAuthnRequestOptions, error)
+	FinishPasskeyLogin(req *dto.PasskeyLoginRequest) (*dto.AuthResponse, error)
+	RefreshToken(req *dto.RefreshTokenRequest) (*dto.AuthResponse, error)
+	Logout(userID uint, accessToken, refreshToken string) error
 }
 
 type UserService interface {
+	ListUsers(req *dto.ListUsersRequest) (*dto.PaginatedResponse, error)
+	// ListUsersByCursor pages with opaque cursors instead of page numbers,
+	// which stays fast and stable on large tables.
+	ListUsersByCursor(req *dto.ListUsersRequest) (*dto.CursorPaginatedResponse, error)
 	GetProfile(userID uint) (*dto.UserResponse, error)
 	UpdateProfile(userID uint, req *dto.UpdateUserRequest) (*dto.UserResponse, error)
 	ChangePassword(userID uint, req *dto.ChangePasswordRequest) error
 	AssignRole(userID uint, roleID uint) error
 	RemoveRole(userID uint, roleID uint) error
+	// UnlockUser clears the failed login counter and lock of an account.
+	UnlockUser(userID uint) error
+	// The admin methods take the acting administrator's ID for the audit
+	// trail; zero stands for an operator using authctl.
+	CreateUser(adminID uint, req *dto.CreateUserRequest) (*dto.UserResponse, error)
+	// DeactivateUser blocks login and revokes all sessions of the user.
+	DeactivateUser(adminID, userID uint, req *dto.UserStatusRequest) error
+	ReactivateUser(adminID, userID uint, req *dto.UserStatusRequest) error
+	// DeleteUser soft-deletes the user and revokes all sessions.
+	DeleteUser(adminID, userID uint) error
+	RestoreUser(adminID, userID uint) error
+	// PurgeUser permanently removes the user and all of its data.
+	PurgeUser(adminID, userID uint) error
+	// SetPassword replaces the password and revokes all sessions.
+	SetPassword(adminID, userID uint, password string) error
+}
+
+type RoleService interface {
+	ListRoles() ([]dto.RoleResponse, error)
+	GetRole(roleID uint) (*dto.RoleResponse, error)
+	CreateRole(req *dto.CreateRoleRequest) (*dto.RoleResponse, error)
+	UpdateRole(roleID uint, req *dto.UpdateRoleRequest) (*dto.RoleResponse, error)
+	// DeleteRole refuses to delete a role still assigned to users unless
+	// force is set, in which case the role is removed from them.
+	DeleteRole(roleID uint, force bool) error
+	AddPermissions(roleID uint, req *dto.RolePermissionsRequest) (*dto.RoleResponse, error)
+	RemovePermission(roleID, permissionID uint) (*dto.RoleResponse, error)
+	AddDeniedPermissions(roleID uint, req *dto.DeniedPermissionsRequest) (*dto.RoleResponse, error)
+	RemoveDeniedPermission(roleID, permissionID uint) (*dto.RoleResponse, error)
+	// SetParents replaces the roles the role inherits from. It refuses
+	// parents that would make the role inherit from itself.
+	SetParents(roleID uint, req *dto.RoleParentsRequest) (*dto.RoleResponse, error)
 }
 
 type PermissionService interface {
+	// CheckPermission reports whether Decide allows the check.
 	CheckPermission(userID uint, resource, action string) (bool, error)
+	// Decide checks a permission and explains the outcome. A denial, of
+	// one of the user's roles or of the user directly, overrides every
+	// grant.
+	Decide(userID uint, resource, action string) (*dto.PermissionDecision, error)
 	GetUserPermissions(userID uint) ([]*entities.Permission, error)
+	ListPermissions() ([]dto.PermissionResponse, error)
+	GetPermission(permissionID uint) (*dto.PermissionResponse, error)
+	CreatePermission(req *dto.PermissionRequest) (*dto.PermissionResponse, error)
+	UpdatePermission(permissionID uint, req *dto.PermissionRequest) (*dto.PermissionResponse, error)
+	// DeletePermission refuses to delete a permission granted to or denied
+	// by roles, or denied to users, unless force is set, in which case the
+	// grants and denials are removed.
+	DeletePermission(permissionID uint, force bool) error
+	// RegisterPermissions creates missing permissions and updates the
+	// descriptions of existing ones, so services can declare what they need
+	// at startup.
+	RegisterPermissions(req *dto.RegisterPermissionsRequest) ([]dto.PermissionResponse, error)
+	// ListUserDenials returns the permissions denied to the user directly.
+	ListUserDenials(userID uint) ([]dto.PermissionResponse, error)
+	DenyUserPermissions(userID uint, req *dto.DeniedPermissionsRequest) ([]dto.PermissionResponse, error)
+	RemoveUserDenial(userID, permissionID uint) ([]dto.PermissionResponse, error)
+}
+
+// RBACService manages the role and permission catalog as a whole through
+// declarative manifests.
+type RBACService interface {
+	// ExportManifest returns the live catalog as a manifest.
+	ExportManifest() (*dto.RBACManifest, error)
+	// PlanManifest validates the manifest and lists the changes ApplyManifest
+	// would make, without making them.
+	PlanManifest(manifest *dto.RBACManifest) (*dto.RBACPlan, error)
+	// ApplyManifest reconciles the catalog with the manifest in a single
+	// transaction and returns the changes made.
+	ApplyManifest(manifest *dto.RBACManifest) (*dto.RBACPlan, error)
+}
+
+type SessionService interface {
+	ListSessions(userID, currentSessionID uint) ([]dto.SessionResponse, error)
+	RevokeSession(userID, sessionID uint) error
+	RevokeOtherSessions(userID, currentSessionID uint) error
+	RevokeAllSessions(userID uint) error
+}
+
+type SigningKeyService interface {
+	// SyncKeys reloads the keyring from the database.
+	SyncKeys() error
+	RotateSigningKey() (*dto.SigningKeyResponse, error)
+	// RotateIfDue rotates when the active key is older than the configured
+	// rotation interval.
+	RotateIfDue() (bool, error)
+	ListSigningKeys() ([]dto.SigningKeyResponse, error)
+	PruneExpiredKeys() error
+}
+
+type MFAService interface {
+	BeginEnrollment(userID uint, req *dto.MFAEnrollRequest) (*dto.MFAEnrollmentResponse, error)
+	ConfirmEnrollment(userID uint, req *dto.MFACodeRequest) (*dto.RecoveryCodesResponse, error)
+	Disable(userID uint, req *dto.MFACodeRequest) error
+	RegenerateRecoveryCodes(userID uint, req *dto.MFACodeRequest) (*dto.RecoveryCodesResponse, error)
+	// VerifySecondFactor accepts either a TOTP code or, when recoveryCode is
+	// set, one of the user's unused recovery codes.
+	VerifySecondFactor(user *entities.User, code, recoveryCode string) error
+}
+
+type WebAuthnService interface {
+	BeginRegistration(userID uint) (*dto.WebAuthnCreationOptions, error)
+	FinishRegistration(userID uint, req *dto.WebAuthnRegistrationRequest) (*dto.WebAuthnCredentialResponse, error)
+	ListCredentials(userID uint) ([]dto.WebAuthnCredentialResponse, error)
+	DeleteCredential(userID, credentialID uint) error
+	HasCredentials(userID uint) (bool, error)
+	// BeginAuthentication issues assertion options for the user's
+	// credentials, or for discoverable credentials when userID is zero.
+	BeginAuthentication(userID uint, requireUserVerification bool) (*dto.WebAuthnRequestOptions, error)
+	// FinishAuthentication verifies an assertion against a challenge issued
+	// for expectedUserID and returns the credential's owner. When it rejects
+	// the assertion of a known credential, it returns the owner along with
+	// the error.
+	FinishAuthentication(assertion *dto.WebAuthnAssertion, expectedUserID uint) (*entities.User, error)
+}
+
+type EmailVerificationService interface {
+	SendVerification(user *entities.User) error
+	VerifyEmail(req *dto.VerifyEmailRequest) error
+	ResendVerification(req *dto.ResendVerificationRequest) error
+}
+
+type PasswordResetService interface {
+	// ForgotPassword mails a reset link if the account exists. It returns
+	// nil for unknown addresses so callers cannot discover accounts.
+	ForgotPassword(req *dto.ForgotPasswordRequest) error
+	// SendInvite mails a link to choose a first password, redeemed through
+	// ResetPassword.
+	SendInvite(user *entities.User) error
+	ResetPassword(req *dto.ResetPasswordRequest) error
+}
+
+type LoginAttemptService interface {
+	// CheckIP returns a 429 error while the IP address is blocked.
+	CheckIP(ipAddress string) error
+	// CheckUser returns a 423 error while the account is locked.
+	CheckUser(user *entities.User) error
+	// RecordFailure counts a failed login for the IP and, when known, the
+	// account. It returns the locked error if this failure locked the
+	// account.
+	RecordFailure(user *entities.User, ipAddress string) error
+	RecordSuccess(user *entities.User) error
 }
@@ -0,0 +1,295 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migrations are numbered SQL files, NNNN_name.up.sql and NNNN_name.down.sql.
+// Versions must only ever be added; an applied migration is never changed.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the Postgres advisory lock key held while migrating, so
+// replicas starting together apply each migration once.
+const migrationLock = 7352003
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	name text NOT NULL,
+	applied_at timestamptz NOT NULL
+)`
+
+// Migration is one numbered schema change.
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	up      string
+	down    string
+}
+
+// MigrationStatus tells whether a migration is applied. Migrations applied
+// by a newer build and unknown to this one are listed as well.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies and rolls back the embedded migrations. Every operation
+// runs in a single transaction holding an advisory lock, so it either
+// completes or leaves the schema as it was. Statements that cannot run in
+// a transaction, such as CREATE INDEX CONCURRENTLY, are not supported.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("Invalid migration file name: %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("Migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" {
+			return nil, fmt.Errorf("Migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Latest returns the version of the newest migration, 0 if there is none.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists all migrations in version order.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var applied []schemaMigration
+	if m.db.Migrator().HasTable(&schemaMigration{}) {
+		if err := m.db.Order("version").Find(&applied).Error; err != nil {
+			return nil, fmt.Errorf("Failed to read applied migrations: %w", err)
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	appliedAt := make(map[int]time.Time, len(applied))
+	for _, record := range applied {
+		appliedAt[record.Version] = record.AppliedAt
+		if m.find(record.Version) == nil {
+			at := record.AppliedAt
+			statuses = append(statuses, MigrationStatus{Version: record.Version, Name: record.Name, AppliedAt: &at})
+		}
+	}
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Pending returns the migrations that are not applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, *m.find(status.Version))
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations and returns them.
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.To(m.Latest())
+}
+
+// Down rolls back the given number of most recently applied migrations and
+// returns them, newest first.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	return m.locked(func(tx *gorm.DB, applied []int) ([]Migration, error) {
+		var done []Migration
+		for i := len(applied) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.find(applied[i])
+			if migration == nil {
+				return nil, fmt.Errorf("Migration %d is unknown to this build and cannot be rolled back", applied[i])
+			}
+			if err := rollback(tx, migration); err != nil {
+				return nil, err
+			}
+			done = append(done, *migration)
+		}
+		return done, nil
+	})
+}
+
+// To applies or rolls back migrations until version is the newest applied
+// one. Version 0 rolls back everything. It returns the migrations in the
+// order they were run.
+func (m *Migrator) To(version int) ([]Migration, error) {
+	if version != 0 && m.find(version) == nil {
+		return nil, fmt.Errorf("Unknown migration version %d", version)
+	}
+
+	return m.locked(func(tx *gorm.DB, applied []int) ([]Migration, error) {
+		isApplied := make(map[int]bool, len(applied))
+		for _, v := range applied {
+			isApplied[v] = true
+			if v > version && m.find(v) == nil {
+				return nil, fmt.Errorf("Migration %d is unknown to this build and cannot be rolled back", v)
+			}
+		}
+
+		var done []Migration
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := &m.migrations[i]
+			if migration.Version > version && isApplied[migration.Version] {
+				if err := rollback(tx, migration); err != nil {
+					return nil, err
+				}
+				done = append(done, *migration)
+			}
+		}
+		for i := range m.migrations {
+			migration := &m.migrations[i]
+			if migration.Version <= version && !isApplied[migration.Version] {
+				if err := apply(tx, migration); err != nil {
+					return nil, err
+				}
+				done = append(done, *migration)
+			}
+		}
+		return done, nil
+	})
+}
+
+// locked runs fn in a transaction holding the migration lock, with the
+// applied versions in ascending order.
+func (m *Migrator) locked(fn func(tx *gorm.DB, applied []int) ([]Migration, error)) ([]Migration, error) {
+	var done []Migration
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLock).Error; err != nil {
+			return fmt.Errorf("Failed to acquire migration lock: %w", err)
+		}
+		if err := tx.Exec(createSchemaMigrations).Error; err != nil {
+			return fmt.Errorf("Failed to create schema_migrations: %w", err)
+		}
+
+		var applied []int
+		if err := tx.Model(&schemaMigration{}).Order("version").Pluck("version", &applied).Error; err != nil {
+			return fmt.Errorf("Failed to read applied migrations: %w", err)
+		}
+
+		var err error
+		done, err = fn(tx, applied)
+		return err
+	})
+	return done, err
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func apply(tx *gorm.DB, migration *Migration) error {
+	if err := tx.Exec(migration.up).Error; err != nil {
+		return fmt.Errorf("Failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return tx.Create(&schemaMigration{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		AppliedAt: time.Now(),
+	}).Error
+}
+
+func rollback(tx *gorm.DB, migration *Migration) error {
+	if strings.TrimSpace(migration.down) == "" {
+		return fmt.Errorf("Migration %d_%s cannot be rolled back", migration.Version, migration.Name)
+	}
+	if err := tx.Exec(migration.down).Error; err != nil {
+		return fmt.Errorf("Failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return tx.Delete(&schemaMigration{}, migration.Version).Error
+}
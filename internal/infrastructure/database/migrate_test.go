@@ -0,0 +1,99 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func migrationFS(files ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range files {
+		fsys["migrations/"+name] = &fstest.MapFile{Data: []byte("-- " + name)}
+	}
+	return fsys
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFS(
+		"0010_later.up.sql",
+		"0002_second.down.sql",
+		"0002_second.up.sql",
+		"0001_initial.up.sql",
+		"9_no_padding.up.sql",
+	))
+	if err != nil {
+		t.Fatalf("loadMigrations error = %v", err)
+	}
+
+	want := []struct {
+		version int
+		name    string
+		down    bool
+	}{
+		{1, "initial", false},
+		{2, "second", true},
+		{9, "no_padding", false},
+		{10, "later", false},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("loadMigrations returned %d migrations, want %d", len(migrations), len(want))
+	}
+	for i, w := range want {
+		m := migrations[i]
+		if m.Version != w.version || m.Name != w.name {
+			t.Errorf("migration %d = %d_%s, want %d_%s", i, m.Version, m.Name, w.version, w.name)
+		}
+		if !strings.HasSuffix(m.up, ".up.sql") {
+			t.Errorf("migration %d up = %q", m.Version, m.up)
+		}
+		if (m.down != "") != w.down {
+			t.Errorf("migration %d down = %q", m.Version, m.down)
+		}
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		fsys  fstest.MapFS
+		error string
+	}{
+		{"no migrations directory", fstest.MapFS{}, "Failed to read migrations"},
+		{"missing direction", migrationFS("0001_initial.sql"), "Invalid migration file name"},
+		{"missing version", migrationFS("initial.up.sql"), "Invalid migration file name"},
+		{"dash separator", migrationFS("0001-initial.up.sql"), "Invalid migration file name"},
+		{"unknown direction", migrationFS("0001_initial.sideways.sql"), "Invalid migration file name"},
+		{"other extension", migrationFS("0001_initial.up.txt"), "Invalid migration file name"},
+		{"two names for one version", migrationFS("0001_initial.up.sql", "0001_other.down.sql"), "Migration 1 has two names"},
+		{"two up names for one version", migrationFS("0001_initial.up.sql", "1_initial_again.up.sql"), "Migration 1 has two names"},
+		{"missing up file", migrationFS("0001_initial.up.sql", "0002_second.down.sql"), "Migration 2_second has no up file"},
+		{"empty up file", fstest.MapFS{"migrations/0001_initial.up.sql": &fstest.MapFile{}}, "Migration 1_initial has no up file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("loadMigrations error = %v, want one containing %q", err, tt.error)
+			}
+		})
+	}
+}
+
+// The shipped migrations must load and each be reversible.
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("loadMigrations error = %v", err)
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s, want version %d", m.Version, m.Name, i+1)
+		}
+		if m.down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS
+    rate_limit_buckets,
+    ip_login_failures,
+    password_reset_tokens,
+    web_authn_challenges,
+    web_authn_credentials,
+    security_events,
+    recovery_codes,
+    signing_keys,
+    sessions,
+    refresh_tokens,
+    revoked_tokens,
+    role_permissions,
+    permissions,
+    user_roles,
+    roles,
+    users;
@@ -0,0 +1,192 @@
+-- Schema as created by GORM's AutoMigrate before versioned migrations were
+-- introduced. Everything is conditional so existing installations adopt it
+-- without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    email text NOT NULL CONSTRAINT uni_users_email UNIQUE,
+    password text NOT NULL,
+    first_name text NOT NULL,
+    last_name text NOT NULL,
+    is_active boolean DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    locale varchar(16),
+    email_verified boolean DEFAULT false,
+    email_verified_at timestamptz,
+    mfa_enabled boolean DEFAULT false,
+    mfa_secret text,
+    mfa_pending_secret text,
+    mfa_last_used_step bigint,
+    failed_login_attempts bigint DEFAULT 0,
+    last_failed_login_at timestamptz,
+    locked_until timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL CONSTRAINT uni_roles_name UNIQUE,
+    description text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    role_id bigint CONSTRAINT fk_user_roles_role REFERENCES roles (id),
+    user_id bigint CONSTRAINT fk_user_roles_user REFERENCES users (id),
+    PRIMARY KEY (role_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL CONSTRAINT uni_permissions_name UNIQUE,
+    resource text NOT NULL,
+    action text NOT NULL,
+    description text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    permission_id bigint CONSTRAINT fk_role_permissions_permission REFERENCES permissions (id),
+    role_id bigint CONSTRAINT fk_role_permissions_role REFERENCES roles (id),
+    PRIMARY KEY (permission_id, role_id)
+);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    id bigserial PRIMARY KEY,
+    jti text NOT NULL,
+    user_id bigint NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_revoked_tokens_jti ON revoked_tokens (jti);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id bigserial PRIMARY KEY,
+    jti text NOT NULL,
+    family_id text NOT NULL,
+    user_id bigint NOT NULL,
+    expires_at timestamptz NOT NULL,
+    consumed_at timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_jti ON refresh_tokens (jti);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    family_id text NOT NULL,
+    device_name text,
+    user_agent text,
+    ip_address text,
+    last_used_at timestamptz,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions (family_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions (expires_at);
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id bigserial PRIMARY KEY,
+    k_id text NOT NULL,
+    algorithm text NOT NULL,
+    private_key text NOT NULL,
+    status text NOT NULL,
+    activated_at timestamptz,
+    retired_at timestamptz,
+    verify_until timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signing_keys_k_id ON signing_keys (k_id);
+CREATE INDEX IF NOT EXISTS idx_signing_keys_status ON signing_keys (status);
+CREATE INDEX IF NOT EXISTS idx_signing_keys_verify_until ON signing_keys (verify_until);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL CONSTRAINT fk_users_recovery_codes REFERENCES users (id) ON DELETE CASCADE,
+    code_hash text NOT NULL,
+    used_at timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    type text NOT NULL,
+    details text,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_security_events_type ON security_events (type);
+
+CREATE TABLE IF NOT EXISTS web_authn_credentials (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    credential_id text NOT NULL,
+    public_key bytea NOT NULL,
+    algorithm bigint NOT NULL,
+    sign_count bigint,
+    aa_guid text,
+    name text,
+    transports text,
+    last_used_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_web_authn_credentials_credential_id ON web_authn_credentials (credential_id);
+CREATE INDEX IF NOT EXISTS idx_web_authn_credentials_user_id ON web_authn_credentials (user_id);
+
+CREATE TABLE IF NOT EXISTS web_authn_challenges (
+    id bigserial PRIMARY KEY,
+    challenge text NOT NULL,
+    user_id bigint,
+    ceremony text NOT NULL,
+    require_user_verification boolean,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_web_authn_challenges_challenge ON web_authn_challenges (challenge);
+CREATE INDEX IF NOT EXISTS idx_web_authn_challenges_user_id ON web_authn_challenges (user_id);
+CREATE INDEX IF NOT EXISTS idx_web_authn_challenges_expires_at ON web_authn_challenges (expires_at);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at timestamptz,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_expires_at ON password_reset_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS ip_login_failures (
+    ip_address varchar(45) PRIMARY KEY,
+    failed_attempts bigint NOT NULL,
+    last_failed_at timestamptz NOT NULL,
+    blocked_until timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_ip_login_failures_last_failed_at ON ip_login_failures (last_failed_at);
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key varchar(255) PRIMARY KEY,
+    tokens decimal NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
@@ -0,0 +1,9 @@
+-- A role inherits the permissions of its parents, transitively.
+
+CREATE TABLE role_parents (
+    role_id bigint CONSTRAINT fk_role_parents_role REFERENCES roles (id),
+    parent_id bigint CONSTRAINT fk_role_parents_parents REFERENCES roles (id),
+    PRIMARY KEY (role_id, parent_id),
+    CONSTRAINT chk_role_parents_not_self CHECK (role_id <> parent_id)
+);
+CREATE INDEX idx_role_parents_parent_id ON role_parents (parent_id);
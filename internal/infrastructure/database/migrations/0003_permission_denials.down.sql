@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_denied_permissions, role_denied_permissions;
@@ -0,0 +1,14 @@
+-- Denied permissions override every grant, at role level (inherited like
+-- grants) and for single users.
+
+CREATE TABLE role_denied_permissions (
+    role_id bigint CONSTRAINT fk_role_denied_permissions_role REFERENCES roles (id),
+    permission_id bigint CONSTRAINT fk_role_denied_permissions_permission REFERENCES permissions (id),
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_denied_permissions (
+    user_id bigint CONSTRAINT fk_user_denied_permissions_user REFERENCES users (id),
+    permission_id bigint CONSTRAINT fk_user_denied_permissions_permission REFERENCES permissions (id),
+    PRIMARY KEY (user_id, permission_id)
+);
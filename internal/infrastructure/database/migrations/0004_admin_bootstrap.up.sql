@@ -0,0 +1,9 @@
+-- Records that the first administrator was bootstrapped, so that
+-- ADMIN_EMAIL is never promoted again, even once no active admin is left.
+
+CREATE TABLE admin_bootstraps (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    email text NOT NULL,
+    created_at timestamptz NOT NULL
+);
@@ -0,0 +1,5 @@
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'user' AND p.name = 'users.read'
+ON CONFLICT DO NOTHING;
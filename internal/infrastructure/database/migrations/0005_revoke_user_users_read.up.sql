@@ -0,0 +1,12 @@
+-- The default user role no longer reads every account; users.read moves
+-- to admin, which inherited it from user until now.
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'users.read'
+ON CONFLICT DO NOTHING;
+
+DELETE FROM role_permissions
+WHERE role_id IN (SELECT id FROM roles WHERE name = 'user')
+  AND permission_id IN (SELECT id FROM permissions WHERE name = 'users.read');
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS rbac_version;
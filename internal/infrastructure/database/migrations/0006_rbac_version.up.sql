@@ -0,0 +1,10 @@
+-- A counter incremented with every change to roles, grants, denials, role
+-- parents and user roles, so that instances caching permission policies
+-- can tell when theirs are stale.
+
+CREATE TABLE rbac_version (
+    id smallint PRIMARY KEY CHECK (id = 1),
+    version bigint NOT NULL
+);
+
+INSERT INTO rbac_version (id, version) VALUES (1, 0);
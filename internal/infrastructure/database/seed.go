@@ -0,0 +1,202 @@
+package database
+
+import (
+	"auth-system/internal/config"
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/infrastructure/repositories"
+	"auth-system/internal/infrastructure/security"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// bootstrapAdminLock is the Postgres advisory lock key that keeps replicas
+// starting together from bootstrapping twice.
+const bootstrapAdminLock = 7352002
+
+// Seed creates the default roles and permissions that are missing and
+// bootstraps the first administrator.
+func Seed(db *gorm.DB, bootstrap *config.BootstrapConfig) error {
+	if err := seedDefaultData(db); err != nil {
+		return err
+	}
+
+	if err := bootstrapAdmin(db, bootstrap); err != nil {
+		return fmt.Errorf("Failed to bootstrap administrator: %w", err)
+	}
+
+	return nil
+}
+
+func seedDefaultData(db *gorm.DB) error {
+	// Create default permissions
+	permissions := []entities.Permission{
+		{Name: "users.read", Resource: "users", Action: "read", Description: "Read user information"},
+		{Name: "users.write", Resource: "users", Action: "write", Description: "Create and update users"},
+		{Name: "users.delete", Resource: "users", Action: "delete", Description: "Delete users"},
+		{Name: "roles.read", Resource: "roles", Action: "read", Description: "Read role information"},
+		{Name: "roles.write", Resource: "roles", Action: "write", Description: "Create and update roles"},
+		{Name: "roles.delete", Resource: "roles", Action: "delete", Description: "Delete roles"},
+	}
+
+	for _, perm := range permissions {
+		var existingPerm entities.Permission
+		if err := db.Where("name = ?", perm.Name).First(&existingPerm).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				if err := db.Create(&perm).Error; err != nil {
+					log.Printf("Error creating permission %s: %v", perm.Name, err)
+				}
+			}
+		}
+	}
+
+	// Create default roles
+	roles := []struct {
+		role        entities.Role
+		permissions []string
+	}{
+		{
+			role: entities.Role{
+				Name:        "admin",
+				Description: "Administrator with full access",
+			},
+			permissions: []string{"users.read", "users.write", "users.delete", "roles.read", "roles.write", "roles.delete"},
+		},
+		{
+			role: entities.Role{
+				Name:        "user",
+				Description: "Regular user with limited access",
+			},
+			permissions: []string{},
+		},
+	}
+
+	for _, roleData := range roles {
+		var existingRole entities.Role
+		if err := db.Where("name = ?", roleData.role.Name).First(&existingRole).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				// Create role
+				if err := db.Create(&roleData.role).Error; err != nil {
+					log.Printf("Error creating role %s: %v", roleData.role.Name, err)
+					continue
+				}
+
+				// Assign permissions to role
+				var perms []entities.Permission
+				if err := db.Where("name IN ?", roleData.permissions).Find(&perms).Error; err == nil {
+					if err := db.Model(&roleData.role).Association("Permissions").Append(&perms); err != nil {
+						log.Printf("Error assigning permissions to role %s: %v", roleData.role.Name, err)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// adminBootstrap records a completed bootstrap. UserID is zero when an
+// administrator already existed, e.g. on installations older than the
+// record.
+type adminBootstrap struct {
+	ID        uint
+	UserID    uint
+	Email     string
+	CreatedAt time.Time
+}
+
+// bootstrapAdmin gives ADMIN_EMAIL the admin role, creating the account with
+// ADMIN_PASSWORD if needed. It runs at most once per installation: as soon
+// as an active admin exists, with or without it, the bootstrap is recorded
+// and never repeated, so the variables cannot promote anyone later.
+//
+// Anyone may register an account, so an existing ADMIN_EMAIL account is
+// only promoted if its email is verified and ADMIN_PASSWORD is its
+// password; otherwise whoever registered the address first would get
+// admin.
+func bootstrapAdmin(db *gorm.DB, cfg *config.BootstrapConfig) error {
+	if cfg.AdminEmail == "" {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", bootstrapAdminLock).Error; err != nil {
+			return err
+		}
+
+		var bootstraps int64
+		if err := tx.Model(&adminBootstrap{}).Count(&bootstraps).Error; err != nil {
+			return err
+		}
+		if bootstraps > 0 {
+			return nil
+		}
+
+		var adminRole entities.Role
+		if err := tx.Where("name = ?", "admin").First(&adminRole).Error; err != nil {
+			return err
+		}
+
+		var admins int64
+		if err := tx.Model(&entities.User{}).
+			Joins("INNER JOIN user_roles ON user_roles.user_id = users.id").
+			Where("user_roles.role_id = ? AND users.is_active", adminRole.ID).
+			Count(&admins).Error; err != nil {
+			return err
+		}
+		if admins > 0 {
+			return tx.Create(&adminBootstrap{Email: cfg.AdminEmail}).Error
+		}
+
+		passwordManager := security.NewPasswordManager()
+		var user entities.User
+		err := tx.Where("email = ?", cfg.AdminEmail).First(&user).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			if len(cfg.AdminPassword) < 6 {
+				return fmt.Errorf("ADMIN_PASSWORD must be at least 6 characters to create %s", cfg.AdminEmail)
+			}
+
+			hashedPassword, err := passwordManager.HashPassword(cfg.AdminPassword)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			user = entities.User{
+				Email:           cfg.AdminEmail,
+				Password:        hashedPassword,
+				FirstName:       "Admin",
+				LastName:        "User",
+				IsActive:        true,
+				EmailVerified:   true,
+				EmailVerifiedAt: &now,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case !user.IsActive:
+			return fmt.Errorf("%s is deactivated", cfg.AdminEmail)
+		case !user.EmailVerified:
+			return fmt.Errorf("%s already exists and its email is not verified; refusing to make it an administrator", cfg.AdminEmail)
+		case cfg.AdminPassword == "" || passwordManager.CheckPassword(user.Password, cfg.AdminPassword) != nil:
+			return fmt.Errorf("%s already exists and ADMIN_PASSWORD is not its password; refusing to make it an administrator", cfg.AdminEmail)
+		}
+
+		// Through the repository, so instances that cached the user's
+		// permissions see the new role.
+		if err := repositories.NewUserRepository(tx).AddRole(user.ID, adminRole.ID); err != nil {
+			return err
+		}
+		if err := tx.Create(&adminBootstrap{UserID: user.ID, Email: user.Email}).Error; err != nil {
+			return err
+		}
+
+		log.Printf("Bootstrapped administrator %s", cfg.AdminEmail)
+		return nil
+	})
+}
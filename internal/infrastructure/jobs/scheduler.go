@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// RunPeriodically invokes fn every interval on a background goroutine until
+// the returned stop function is called.
+func RunPeriodically(name string, interval time.Duration, fn func() error) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := fn(); err != nil {
+					log.Printf("Job %s failed: %v", name, err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
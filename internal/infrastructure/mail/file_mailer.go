@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes each message as an .eml file into a directory, where it
+// can be opened with any mail client. Meant for development and tests.
+type FileMailer struct {
+	dir  string
+	from *netmail.Address
+}
+
+func NewFileMailer(from, dir string) (*FileMailer, error) {
+	address, err := netmail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	return &FileMailer{dir: dir, from: address}, nil
+}
+
+func (m *FileMailer) Send(message *Message) error {
+	now := time.Now()
+	data, err := buildMIME(m.from, message, now)
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o600)
+}
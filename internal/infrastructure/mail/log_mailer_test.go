@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLogMailerOmitsBody(t *testing.T) {
+	var output bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&output)
+
+	message := &Message{
+		To:      "user@example.com",
+		Subject: "Reset your password",
+		Text:    "https://example.com/reset-password?token=secret-token",
+		HTML:    `<a href="https://example.com/reset-password?token=secret-token">Reset</a>`,
+	}
+	if err := NewLogMailer("no-reply@example.com").Send(message); err != nil {
+		t.Fatalf("Send error = %v", err)
+	}
+
+	logged := output.String()
+	if strings.Contains(logged, "secret-token") {
+		t.Errorf("log contains the body: %s", logged)
+	}
+	if !strings.Contains(logged, "user@example.com") || !strings.Contains(logged, "Reset your password") {
+		t.Errorf("log = %q, want the recipient and subject", logged)
+	}
+}
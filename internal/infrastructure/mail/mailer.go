@@ -0,0 +1,22 @@
+package mail
+
+import "errors"
+
+// Message is a rendered email. At least one of Text and HTML is set.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers messages. Drivers are selected with MAIL_DRIVER and are
+// usually wrapped in a Queue so callers never wait for delivery.
+type Mailer interface {
+	Send(message *Message) error
+}
+
+var (
+	ErrQueueFull   = errors.New("mail queue is full")
+	ErrQueueClosed = errors.New("mail queue is closed")
+)
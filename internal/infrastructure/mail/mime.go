@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// buildMIME renders message as an RFC 5322 message, using
+// multipart/alternative when both a text and an HTML body are present.
+func buildMIME(from *netmail.Address, message *Message, now time.Time) ([]byte, error) {
+	to, err := netmail.ParseAddress(message.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient: %w", err)
+	}
+	if strings.ContainsAny(message.Subject, "\r\n") {
+		return nil, fmt.Errorf("invalid subject")
+	}
+
+	messageID, err := newMessageID(from)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+	writeHeader("From", from.String())
+	writeHeader("To", to.String())
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	writeHeader("Date", now.Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID)
+	writeHeader("MIME-Version", "1.0")
+
+	switch {
+	case message.Text != "" && message.HTML != "":
+		parts := multipart.NewWriter(&buf)
+		writeHeader("Content-Type", `multipart/alternative; boundary="`+parts.Boundary()+`"`)
+		buf.WriteString("\r\n")
+		if err := writePart(parts, "text/plain", message.Text); err != nil {
+			return nil, err
+		}
+		if err := writePart(parts, "text/html", message.HTML); err != nil {
+			return nil, err
+		}
+		if err := parts.Close(); err != nil {
+			return nil, err
+		}
+	case message.HTML != "":
+		writeBody(&buf, writeHeader, "text/html", message.HTML)
+	default:
+		writeBody(&buf, writeHeader, "text/plain", message.Text)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writePart(parts *multipart.Writer, contentType, body string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	part, err := parts.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	encoder := quotedprintable.NewWriter(part)
+	if _, err := encoder.Write([]byte(body)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func writeBody(buf *bytes.Buffer, writeHeader func(key, value string), contentType, body string) {
+	writeHeader("Content-Type", contentType+"; charset=utf-8")
+	writeHeader("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+	encoder := quotedprintable.NewWriter(buf)
+	encoder.Write([]byte(body))
+	encoder.Close()
+}
+
+func newMessageID(from *netmail.Address) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
@@ -0,0 +1,141 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Queue sends messages on background workers so request handlers never wait
+// for delivery. Failed sends are retried with exponential backoff. Queued
+// messages live in memory only and are lost if the process is killed.
+type Queue struct {
+	mailer      Mailer
+	messages    chan *Message
+	maxAttempts int
+	backoff     time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+
+	// stop is closed when Shutdown gives up waiting; workers then drop
+	// what is left instead of retrying.
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewQueue(mailer Mailer, size, workers, maxAttempts int, backoff time.Duration) *Queue {
+	q := &Queue{
+		mailer:      mailer,
+		messages:    make(chan *Message, size),
+		maxAttempts: max(maxAttempts, 1),
+		backoff:     backoff,
+		stop:        make(chan struct{}),
+	}
+
+	for range max(workers, 1) {
+		q.wg.Add(1)
+		go q.work()
+	}
+
+	return q
+}
+
+// Send enqueues message. It returns ErrQueueFull instead of blocking when
+// the queue is at capacity.
+func (q *Queue) Send(message *Message) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case q.messages <- message:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting messages and waits for the queued ones, including
+// their retries, to finish.
+func (q *Queue) Close() {
+	q.Shutdown(context.Background())
+}
+
+// Shutdown is Close with a deadline: once ctx is done, pending retries are
+// cut short and messages not yet sent are dropped. It still waits for
+// sends in progress and returns ctx.Err() if messages were dropped.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.messages)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.stopOnce.Do(func() { close(q.stop) })
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for message := range q.messages {
+		q.deliver(message)
+	}
+}
+
+func (q *Queue) deliver(message *Message) {
+	delay := q.backoff
+	for attempt := 1; ; attempt++ {
+		if q.stopped() {
+			log.Printf("Mail %q to %s dropped at shutdown after %d attempts", message.Subject, message.To, attempt-1)
+			return
+		}
+
+		err := q.mailer.Send(message)
+		if err == nil {
+			return
+		}
+
+		if attempt >= q.maxAttempts {
+			log.Printf("Mail %q to %s dropped after %d attempts: %v", message.Subject, message.To, attempt, err)
+			return
+		}
+
+		log.Printf("Mail %q to %s failed (attempt %d), retrying in %s: %v", message.Subject, message.To, attempt, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-q.stop:
+			timer.Stop()
+		}
+		delay *= 2
+	}
+}
+
+func (q *Queue) stopped() bool {
+	select {
+	case <-q.stop:
+		return true
+	default:
+		return false
+	}
+}
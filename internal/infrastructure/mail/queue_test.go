@@ -0,0 +1,176 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyMailer fails the first failures sends of every message.
+type flakyMailer struct {
+	failures int
+
+	mu       sync.Mutex
+	attempts map[string]int
+	sent     []string
+}
+
+func (m *flakyMailer) Send(message *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.attempts == nil {
+		m.attempts = make(map[string]int)
+	}
+	m.attempts[message.Subject]++
+	if m.attempts[message.Subject] <= m.failures {
+		return errors.New("temporary failure")
+	}
+	m.sent = append(m.sent, message.Subject)
+	return nil
+}
+
+func TestQueueRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		maxAttempts  int
+		wantAttempts int
+		wantSent     bool
+	}{
+		{"first attempt", 0, 3, 1, true},
+		{"after retries", 2, 3, 3, true},
+		{"dropped after max attempts", 5, 3, 3, false},
+		{"at least one attempt", 1, 0, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := &flakyMailer{failures: tt.failures}
+			queue := NewQueue(mailer, 10, 2, tt.maxAttempts, time.Millisecond)
+
+			if err := queue.Send(&Message{To: "user@example.com", Subject: "hello"}); err != nil {
+				t.Fatalf("Send error = %v", err)
+			}
+			// Close waits for the message and its retries.
+			queue.Close()
+
+			if got := mailer.attempts["hello"]; got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if sent := len(mailer.sent) == 1; sent != tt.wantSent {
+				t.Errorf("sent = %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestQueueBacksOffExponentially(t *testing.T) {
+	mailer := &flakyMailer{failures: 3}
+	queue := NewQueue(mailer, 1, 1, 4, 10*time.Millisecond)
+
+	start := time.Now()
+	if err := queue.Send(&Message{To: "user@example.com", Subject: "hello"}); err != nil {
+		t.Fatalf("Send error = %v", err)
+	}
+	queue.Close()
+
+	// 10ms + 20ms + 40ms between the four attempts.
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("retries took %s, want at least 70ms", elapsed)
+	}
+	if len(mailer.sent) != 1 {
+		t.Errorf("sent %d messages, want 1", len(mailer.sent))
+	}
+}
+
+func TestQueueShutdownCutsRetriesShort(t *testing.T) {
+	mailer := &flakyMailer{failures: 100}
+	queue := NewQueue(mailer, 10, 1, 5, time.Hour)
+
+	for _, subject := range []string{"first", "second"} {
+		if err := queue.Send(&Message{To: "user@example.com", Subject: subject}); err != nil {
+			t.Fatalf("Send error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := queue.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown took %s waiting for a retry", elapsed)
+	}
+
+	// The first message was tried once; the second was dropped unsent.
+	if got := mailer.attempts["first"]; got != 1 {
+		t.Errorf("first message attempts = %d, want 1", got)
+	}
+	if got := mailer.attempts["second"]; got != 0 {
+		t.Errorf("second message attempts = %d, want 0", got)
+	}
+}
+
+func TestQueueShutdownDrains(t *testing.T) {
+	mailer := &flakyMailer{failures: 1}
+	queue := NewQueue(mailer, 10, 1, 2, time.Millisecond)
+
+	for _, subject := range []string{"first", "second"} {
+		if err := queue.Send(&Message{To: "user@example.com", Subject: subject}); err != nil {
+			t.Fatalf("Send error = %v", err)
+		}
+	}
+
+	if err := queue.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown error = %v", err)
+	}
+	if len(mailer.sent) != 2 {
+		t.Errorf("sent %d messages, want 2", len(mailer.sent))
+	}
+}
+
+// blockingMailer holds every send until release is closed.
+type blockingMailer struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingMailer) Send(message *Message) error {
+	m.started <- struct{}{}
+	<-m.release
+	return nil
+}
+
+func TestQueueFull(t *testing.T) {
+	mailer := &blockingMailer{started: make(chan struct{}, 10), release: make(chan struct{})}
+	queue := NewQueue(mailer, 1, 1, 1, time.Millisecond)
+
+	message := &Message{To: "user@example.com", Subject: "hello"}
+	if err := queue.Send(message); err != nil {
+		t.Fatalf("Send error = %v", err)
+	}
+	// The worker holds the first message; the second fills the buffer.
+	<-mailer.started
+	if err := queue.Send(message); err != nil {
+		t.Fatalf("Send error = %v", err)
+	}
+
+	if err := queue.Send(message); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Send on a full queue error = %v, want ErrQueueFull", err)
+	}
+
+	close(mailer.release)
+	queue.Close()
+
+	if err := queue.Send(message); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Send on a closed queue error = %v, want ErrQueueClosed", err)
+	}
+	if got := len(mailer.started); got != 1 {
+		t.Errorf("delivered %d more messages after the first, want 1", got)
+	}
+}
@@ -0,0 +1,132 @@
+package mail
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTP connection security modes.
+const (
+	SMTPSecurityStartTLS = "starttls" // plain connection upgraded with STARTTLS
+	SMTPSecurityTLS      = "tls"      // implicit TLS, usually port 465
+	SMTPSecurityNone     = "none"     // local sinks such as MailHog only
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Security string
+	Timeout  time.Duration
+}
+
+type SMTPMailer struct {
+	config SMTPConfig
+	from   *netmail.Address
+}
+
+func NewSMTPMailer(from string, config SMTPConfig) (*SMTPMailer, error) {
+	address, err := netmail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	switch config.Security {
+	case SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security mode: %s", config.Security)
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &SMTPMailer{config: config, from: address}, nil
+}
+
+func (m *SMTPMailer) Send(message *Message) error {
+	data, err := buildMIME(m.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	recipient, err := netmail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	client, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	if err := client.Rcpt(recipient.Address); err != nil {
+		return fmt.Errorf("SMTP RCPT TO failed: %w", err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (m *SMTPMailer) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+	dialer := &net.Dialer{Timeout: m.config.Timeout}
+	tlsConfig := &tls.Config{ServerName: m.config.Host}
+
+	var conn net.Conn
+	var err error
+	if m.config.Security == SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(m.config.Timeout))
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+
+	if m.config.Security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
@@ -0,0 +1,251 @@
+package mail
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpSink is a minimal SMTP server on a local listener that records what
+// it receives.
+type smtpSink struct {
+	listener   net.Listener
+	startTLS   bool
+	rejectRcpt bool
+
+	mu         sync.Mutex
+	deliveries []smtpDelivery
+}
+
+type smtpDelivery struct {
+	auth string
+	from string
+	to   []string
+	data []byte
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &smtpSink{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	return sink
+}
+
+func (s *smtpSink) config(security string) SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return SMTPConfig{Host: host, Port: port, Security: security, Timeout: 5 * time.Second}
+}
+
+func (s *smtpSink) serve(raw net.Conn) {
+	conn := textproto.NewConn(raw)
+	defer conn.Close()
+
+	var delivery smtpDelivery
+	conn.PrintfLine("220 sink ESMTP")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			conn.PrintfLine("250-sink")
+			if s.startTLS {
+				conn.PrintfLine("250-STARTTLS")
+			}
+			conn.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			delivery.auth = string(credentials)
+			conn.PrintfLine("235 Authenticated")
+		case "MAIL":
+			delivery.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			conn.PrintfLine("250 OK")
+		case "RCPT":
+			if s.rejectRcpt {
+				conn.PrintfLine("550 No such user")
+				continue
+			}
+			delivery.to = append(delivery.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			conn.PrintfLine("250 OK")
+		case "DATA":
+			conn.PrintfLine("354 Go ahead")
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			delivery.data = data
+			s.mu.Lock()
+			s.deliveries = append(s.deliveries, delivery)
+			s.mu.Unlock()
+			conn.PrintfLine("250 Queued")
+		case "QUIT":
+			conn.PrintfLine("221 Bye")
+			return
+		default:
+			conn.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func (s *smtpSink) received() []smtpDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpDelivery{}, s.deliveries...)
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	sink := newSMTPSink(t)
+	config := sink.config(SMTPSecurityNone)
+	config.Username = "mailer"
+	config.Password = "secret"
+
+	mailer, err := NewSMTPMailer("Auth System <noreply@example.com>", config)
+	if err != nil {
+		t.Fatalf("NewSMTPMailer error = %v", err)
+	}
+
+	err = mailer.Send(&Message{
+		To:      "Ana <ana@example.com>",
+		Subject: "Đặt lại mật khẩu",
+		// The leading dot must survive SMTP dot-stuffing.
+		Text: "Hi Ana,\n.hidden line\n",
+		HTML: "<p>Hi Ana</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send error = %v", err)
+	}
+
+	deliveries := sink.received()
+	if len(deliveries) != 1 {
+		t.Fatalf("sink received %d messages, want 1", len(deliveries))
+	}
+	delivery := deliveries[0]
+	if delivery.auth != "\x00mailer\x00secret" {
+		t.Errorf("AUTH PLAIN = %q", delivery.auth)
+	}
+	if delivery.from != "noreply@example.com" || len(delivery.to) != 1 || delivery.to[0] != "ana@example.com" {
+		t.Errorf("envelope = %s -> %v", delivery.from, delivery.to)
+	}
+
+	message, err := netmail.ReadMessage(strings.NewReader(string(delivery.data)))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil || subject != "Đặt lại mật khẩu" {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	if to := message.Header.Get("To"); to != `"Ana" <ana@example.com>` {
+		t.Errorf("To = %q", to)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", mediaType, err)
+	}
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid part: %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	want := []string{
+		"text/plain; charset=utf-8: Hi Ana,\n.hidden line\n",
+		"text/html; charset=utf-8: <p>Hi Ana</p>",
+	}
+	if strings.Join(bodies, "|") != strings.Join(want, "|") {
+		t.Errorf("parts = %q, want %q", bodies, want)
+	}
+}
+
+func TestSMTPMailerSendErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		security string
+		startTLS bool
+		reject   bool
+		to       string
+		want     string
+	}{
+		{"rejected recipient", SMTPSecurityNone, false, true, "ana@example.com", "RCPT TO"},
+		{"STARTTLS not offered", SMTPSecurityStartTLS, false, false, "ana@example.com", "does not support STARTTLS"},
+		{"invalid recipient", SMTPSecurityNone, false, false, "not an address", "invalid recipient"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newSMTPSink(t)
+			sink.startTLS = tt.startTLS
+			sink.rejectRcpt = tt.reject
+
+			mailer, err := NewSMTPMailer("noreply@example.com", sink.config(tt.security))
+			if err != nil {
+				t.Fatalf("NewSMTPMailer error = %v", err)
+			}
+
+			err = mailer.Send(&Message{To: tt.to, Subject: "Hello", Text: "Hi"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Send error = %v, want one mentioning %q", err, tt.want)
+			}
+			if got := len(sink.received()); got != 0 {
+				t.Errorf("sink received %d messages, want none", got)
+			}
+		})
+	}
+}
+
+func TestSMTPMailerConnectionRefused(t *testing.T) {
+	sink := newSMTPSink(t)
+	config := sink.config(SMTPSecurityNone)
+	sink.listener.Close()
+
+	mailer, err := NewSMTPMailer("noreply@example.com", config)
+	if err != nil {
+		t.Fatalf("NewSMTPMailer error = %v", err)
+	}
+	if err := mailer.Send(&Message{To: "ana@example.com", Subject: "Hello", Text: "Hi"}); err == nil {
+		t.Error("Send succeeded without a server")
+	}
+}
+
+func TestNewSMTPMailerValidation(t *testing.T) {
+	if _, err := NewSMTPMailer("not an address", SMTPConfig{Security: SMTPSecurityNone}); err == nil {
+		t.Error("NewSMTPMailer accepted an invalid sender")
+	}
+	if _, err := NewSMTPMailer("noreply@example.com", SMTPConfig{Security: "ssl"}); err == nil {
+		t.Error("NewSMTPMailer accepted an unknown security mode")
+	}
+}
@@ -0,0 +1,109 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// DefaultTemplates returns the templates shipped with the binary.
+func DefaultTemplates() fs.FS {
+	templates, _ := fs.Sub(embeddedTemplates, "templates")
+	return templates
+}
+
+// Templates renders messages from "<name>.<locale>.txt" and optional
+// "<name>.<locale>.html" files. The text template must define a "subject"
+// block. Locales fall back from "pt-BR" to "pt" and then to the default.
+type Templates struct {
+	fsys          fs.FS
+	defaultLocale string
+}
+
+func NewTemplates(fsys fs.FS, defaultLocale string) *Templates {
+	return &Templates{fsys: fsys, defaultLocale: defaultLocale}
+}
+
+var templateFuncs = map[string]any{
+	"duration": formatDuration,
+}
+
+// Render renders the named template for locale and returns a message
+// addressed to to.
+func (t *Templates) Render(name, locale, to string, data any) (*Message, error) {
+	resolved, err := t.resolveLocale(name, locale)
+	if err != nil {
+		return nil, err
+	}
+	base := name + "." + resolved
+
+	textTemplate, err := texttemplate.New(base+".txt").Funcs(templateFuncs).ParseFS(t.fsys, base+".txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mail template %s: %w", base, err)
+	}
+
+	var subject, text bytes.Buffer
+	if err := textTemplate.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render subject of %s: %w", base, err)
+	}
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render mail template %s: %w", base, err)
+	}
+
+	message := &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}
+
+	if _, err := fs.Stat(t.fsys, base+".html"); err == nil {
+		htmlTemplate, err := htmltemplate.New(base+".html").Funcs(templateFuncs).ParseFS(t.fsys, base+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse mail template %s.html: %w", base, err)
+		}
+
+		var html bytes.Buffer
+		if err := htmlTemplate.Execute(&html, data); err != nil {
+			return nil, fmt.Errorf("failed to render mail template %s.html: %w", base, err)
+		}
+		message.HTML = html.String()
+	}
+
+	return message, nil
+}
+
+func (t *Templates) resolveLocale(name, locale string) (string, error) {
+	candidates := []string{locale}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, t.defaultLocale, "en")
+
+	for _, candidate := range candidates {
+		if candidate == "" || strings.ContainsAny(candidate, "./\\") {
+			continue
+		}
+		if _, err := fs.Stat(t.fsys, name+"."+candidate+".txt"); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("mail template %s not found", name)
+}
+
+// formatDuration prints whole hours or minutes, e.g. "24h" or "30m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
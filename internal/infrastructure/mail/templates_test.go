@@ -0,0 +1,124 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func testTemplates(defaultLocale string) *Templates {
+	return NewTemplates(fstest.MapFS{
+		"greet.en.txt":  {Data: []byte(`{{define "subject"}}Hello{{end}}Hi {{.Name}}`)},
+		"greet.en.html": {Data: []byte(`<p>Hi {{.Name}}</p>`)},
+		"greet.pt.txt":  {Data: []byte(`{{define "subject"}}Olá{{end}}Oi {{.Name}}`)},
+		"greet.de.txt":  {Data: []byte(`{{define "subject"}}Hallo{{end}}Hallo {{.Name}}`)},
+		"bad.en.txt":    {Data: []byte(`no subject`)},
+	}, defaultLocale)
+}
+
+func TestTemplatesLocaleFallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		locale        string
+		defaultLocale string
+		wantSubject   string
+	}{
+		{"exact locale", "pt", "en", "Olá"},
+		{"region falls back to language", "pt-BR", "en", "Olá"},
+		{"underscore region", "pt_PT", "en", "Olá"},
+		{"unknown locale uses the default", "fr", "de", "Hallo"},
+		{"unknown region and language use the default", "fr-CA", "de", "Hallo"},
+		{"empty locale uses the default", "", "de", "Hallo"},
+		{"missing default falls back to English", "fr", "es", "Hello"},
+		{"paths are not locales", "../greet.de", "en", "Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := testTemplates(tt.defaultLocale).Render("greet", tt.locale, "user@example.com", map[string]any{"Name": "Ana"})
+			if err != nil {
+				t.Fatalf("Render error = %v", err)
+			}
+			if message.Subject != tt.wantSubject {
+				t.Errorf("Render(%q) subject = %q, want %q", tt.locale, message.Subject, tt.wantSubject)
+			}
+		})
+	}
+}
+
+func TestTemplatesRender(t *testing.T) {
+	templates := testTemplates("en")
+
+	message, err := templates.Render("greet", "en", "user@example.com", map[string]any{"Name": "<Ana>"})
+	if err != nil {
+		t.Fatalf("Render error = %v", err)
+	}
+	if message.To != "user@example.com" || message.Text != "Hi <Ana>\n" {
+		t.Errorf("Render = %+v", message)
+	}
+	if message.HTML != "<p>Hi &lt;Ana&gt;</p>" {
+		t.Errorf("Render HTML = %q, want the name escaped", message.HTML)
+	}
+
+	// Locales without an HTML template send text only.
+	message, err = templates.Render("greet", "pt", "user@example.com", map[string]any{"Name": "Ana"})
+	if err != nil {
+		t.Fatalf("Render error = %v", err)
+	}
+	if message.HTML != "" {
+		t.Errorf("Render HTML = %q, want none", message.HTML)
+	}
+}
+
+func TestTemplatesRenderErrors(t *testing.T) {
+	templates := testTemplates("en")
+
+	if _, err := templates.Render("missing", "en", "user@example.com", nil); err == nil {
+		t.Error("Render of a missing template succeeded")
+	}
+	if _, err := templates.Render("bad", "en", "user@example.com", nil); err == nil {
+		t.Error("Render of a template without a subject succeeded")
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	templates := NewTemplates(DefaultTemplates(), "en")
+	data := map[string]any{
+		"Name":      "Ana",
+		"Link":      "https://example.com/link?token=abc",
+		"ExpiresIn": 24 * time.Hour,
+	}
+
+	for _, name := range []string{"invite", "reset_password", "verify_email"} {
+		for _, locale := range []string{"en", "vi"} {
+			message, err := templates.Render(name, locale, "user@example.com", data)
+			if err != nil {
+				t.Errorf("Render(%s, %s) error = %v", name, locale, err)
+				continue
+			}
+			if message.Subject == "" || message.HTML == "" || !strings.Contains(message.Text, "https://example.com/link?token=abc") {
+				t.Errorf("Render(%s, %s) = %+v", name, locale, message)
+			}
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{24 * time.Hour, "24h"},
+		{time.Hour, "1h"},
+		{30 * time.Minute, "30m"},
+		{90 * time.Minute, "90m"},
+		{59*time.Minute + 50*time.Second, "1h"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDuration(tt.duration); got != tt.want {
+			t.Errorf("formatDuration(%s) = %q, want %q", tt.duration, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ipLoginFailureRepository struct {
+	db *gorm.DB
+}
+
+func NewIPLoginFailureRepository(db *gorm.DB) repositories.IPLoginFailureRepository {
+	return &ipLoginFailureRepository{db: db}
+}
+
+func (r *ipLoginFailureRepository) Get(ipAddress string) (*entities.IPLoginFailure, error) {
+	var failure entities.IPLoginFailure
+	err := r.db.Where("ip_address = ?", ipAddress).First(&failure).Error
+	if err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+func (r *ipLoginFailureRepository) RecordFailure(ipAddress string, now, resetBefore time.Time) (int, error) {
+	var attempts int
+	err := r.db.Raw(`
+		INSERT INTO ip_login_failures (ip_address, failed_attempts, last_failed_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (ip_address) DO UPDATE SET
+			failed_attempts = CASE
+				WHEN ip_login_failures.last_failed_at < ? THEN 1
+				ELSE ip_login_failures.failed_attempts + 1
+			END,
+			last_failed_at = EXCLUDED.last_failed_at
+		RETURNING failed_attempts`, ipAddress, now, resetBefore).Scan(&attempts).Error
+	return attempts, err
+}
+
+func (r *ipLoginFailureRepository) Block(ipAddress string, until time.Time) error {
+	return r.db.Model(&entities.IPLoginFailure{}).
+		Where("ip_address = ?", ipAddress).
+		Update("blocked_until", until).Error
+}
+
+func (r *ipLoginFailureRepository) DeleteStale(before time.Time) (int64, error) {
+	result := r.db.
+		Where("last_failed_at < ? AND (blocked_until IS NULL OR blocked_until < ?)", before, before).
+		Delete(&entities.IPLoginFailure{})
+	return result.RowsAffected, result.Error
+}
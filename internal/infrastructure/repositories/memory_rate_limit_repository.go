@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/repositories"
+	"sync"
+	"time"
+)
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// memoryRateLimitRepository keeps token buckets in process memory. Limits
+// are per instance, so it is only suitable for single-instance deployments.
+type memoryRateLimitRepository struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+func NewMemoryRateLimitRepository() repositories.RateLimitRepository {
+	return &memoryRateLimitRepository{buckets: make(map[string]*memoryBucket)}
+}
+
+func (r *memoryRateLimitRepository) Take(key string, rate float64, burst int, now time.Time) (bool, time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket, exists := r.buckets[key]
+	if !exists {
+		bucket = &memoryBucket{tokens: float64(burst), updatedAt: now}
+		r.buckets[key] = bucket
+	}
+
+	allowed, wait := takeToken(&bucket.tokens, &bucket.updatedAt, rate, burst, now)
+	return allowed, wait, nil
+}
+
+func (r *memoryRateLimitRepository) DeleteStale(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key, bucket := range r.buckets {
+		if bucket.updatedAt.Before(before) {
+			delete(r.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
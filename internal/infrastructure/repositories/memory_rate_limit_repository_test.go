@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitTokenBucket(t *testing.T) {
+	// A burst of 3, refilled at one token per second.
+	const rate, burst = 1.0, 3
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name        string
+		at          time.Duration
+		wantAllowed bool
+		wantWait    time.Duration
+	}{
+		{"burst 1", 0, true, 0},
+		{"burst 2", 0, true, 0},
+		{"burst 3", 0, true, 0},
+		{"empty", 0, false, time.Second},
+		{"half refilled", 500 * time.Millisecond, false, 500 * time.Millisecond},
+		{"refilled", time.Second, true, 0},
+		{"empty again", time.Second, false, time.Second},
+		{"two tokens refilled", 3 * time.Second, true, 0},
+		{"second refilled token", 3 * time.Second, true, 0},
+		{"empty after refill", 3 * time.Second, false, time.Second},
+		// A bucket idle for longer than it takes to fill holds only burst.
+		{"idle 1", time.Hour, true, 0},
+		{"idle 2", time.Hour, true, 0},
+		{"idle 3", time.Hour, true, 0},
+		{"capped at burst", time.Hour, false, time.Second},
+		// A clock going backwards neither refills nor drains.
+		{"clock skew", time.Hour - time.Minute, false, time.Second},
+	}
+
+	repo := NewMemoryRateLimitRepository()
+	for _, step := range steps {
+		allowed, wait, err := repo.Take("login:ip:192.0.2.1", rate, burst, start.Add(step.at))
+		if err != nil {
+			t.Fatalf("%s: Take error = %v", step.name, err)
+		}
+		if allowed != step.wantAllowed || wait.Round(time.Millisecond) != step.wantWait {
+			t.Errorf("%s: Take = %v, %s; want %v, %s", step.name, allowed, wait, step.wantAllowed, step.wantWait)
+		}
+	}
+}
+
+func TestMemoryRateLimitKeys(t *testing.T) {
+	repo := NewMemoryRateLimitRepository()
+	now := time.Now()
+
+	if allowed, _, _ := repo.Take("a", 1, 1, now); !allowed {
+		t.Fatal("first take of a was refused")
+	}
+	if allowed, _, _ := repo.Take("a", 1, 1, now); allowed {
+		t.Error("second take of a was allowed")
+	}
+	if allowed, _, _ := repo.Take("b", 1, 1, now); !allowed {
+		t.Error("bucket b was drained by a")
+	}
+}
+
+func TestMemoryRateLimitDeleteStale(t *testing.T) {
+	repo := NewMemoryRateLimitRepository()
+	now := time.Now()
+
+	repo.Take("old", 1, 1, now.Add(-time.Hour))
+	repo.Take("new", 1, 1, now)
+
+	deleted, err := repo.DeleteStale(now.Add(-time.Minute))
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteStale = %d, %v; want 1, nil", deleted, err)
+	}
+
+	// The deleted bucket starts full again; the kept one is still empty.
+	if allowed, _, _ := repo.Take("old", 1, 1, now); !allowed {
+		t.Error("deleted bucket was not reset")
+	}
+	if allowed, _, _ := repo.Take("new", 1, 1, now); allowed {
+		t.Error("kept bucket was reset")
+	}
+}
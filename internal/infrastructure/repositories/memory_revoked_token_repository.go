@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"sync"
+	"time"
+)
+
+// memoryRevokedTokenRepository keeps revoked token IDs in process memory.
+// It is only suitable for single-instance deployments and development.
+type memoryRevokedTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+}
+
+func NewMemoryRevokedTokenRepository() repositories.RevokedTokenRepository {
+	return &memoryRevokedTokenRepository{tokens: make(map[string]time.Time)}
+}
+
+func (r *memoryRevokedTokenRepository) Revoke(token *entities.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tokens[token.JTI]; !exists {
+		r.tokens[token.JTI] = token.ExpiresAt
+	}
+	return nil
+}
+
+func (r *memoryRevokedTokenRepository) IsRevoked(jti string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.tokens[jti]
+	return exists, nil
+}
+
+func (r *memoryRevokedTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for jti, expiresAt := range r.tokens {
+		if expiresAt.Before(before) {
+			delete(r.tokens, jti)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
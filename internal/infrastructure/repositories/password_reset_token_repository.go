@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type passwordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB) repositories.PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+func (r *passwordResetTokenRepository) Create(token *entities.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *passwordResetTokenRepository) GetByTokenHash(tokenHash string) (*entities.PasswordResetToken, error) {
+	var token entities.PasswordResetToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetTokenRepository) MarkUsed(id uint) (bool, error) {
+	result := r.db.Model(&entities.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *passwordResetTokenRepository) DeleteByUserID(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&entities.PasswordResetToken{}).Error
+}
+
+func (r *passwordResetTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&entities.PasswordResetToken{})
+	return result.RowsAffected, result.Error
+}
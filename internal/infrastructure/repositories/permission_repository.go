@@ -5,6 +5,7 @@ import (
 	"auth-system/internal/domain/repositories"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type permissionRepository struct {
@@ -38,30 +39,120 @@ func (r *permissionRepository) GetByName(name string) (*entities.Permission, err
 }
 
 func (r *permissionRepository) Update(permission *entities.Permission) error {
-	return r.db.Save(permission).Error
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Omit("Roles").Save(permission).Error
+	})
 }
 
 func (r *permissionRepository) Delete(id uint) error {
-	return r.db.Delete(&entities.Permission{}, id).Error
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		for _, table := range []string{"role_permissions", "role_denied_permissions", "user_denied_permissions"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE permission_id = ?", id).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&entities.Permission{}, id).Error
+	})
 }
 
 func (r *permissionRepository) List() ([]*entities.Permission, error) {
 	var permissions []*entities.Permission
-	err := r.db.Find(&permissions).Error
+	err := r.db.Order("name").Find(&permissions).Error
 	return permissions, err
 }
 
 func (r *permissionRepository) GetByUserID(userID uint) ([]*entities.Permission, error) {
 	var permissions []*entities.Permission
 
+	// The user's roles and, transitively, their parents. UNION drops rows
+	// already produced, so the recursion stops even if the hierarchy has a
+	// cycle.
 	query := `
+		WITH RECURSIVE granted_roles (id) AS (
+			SELECT ur.role_id FROM user_roles ur WHERE ur.user_id = ?
+			UNION
+			SELECT rp.parent_id FROM role_parents rp
+			INNER JOIN granted_roles gr ON rp.role_id = gr.id
+		)
 		SELECT DISTINCT p.* FROM permissions p
 		INNER JOIN role_permissions rp ON p.id = rp.permission_id
-		INNER JOIN roles r ON rp.role_id = r.id
-		INNER JOIN user_roles ur ON r.id = ur.role_id
-		WHERE ur.user_id = ?
+		WHERE rp.role_id IN (SELECT id FROM granted_roles)
 	`
 
 	err := r.db.Raw(query, userID).Scan(&permissions).Error
 	return permissions, err
 }
+
+func (r *permissionRepository) CountRoles(permissionID uint) (int64, error) {
+	var count int64
+	err := r.db.Raw(`
+		SELECT COUNT(DISTINCT role_id) FROM (
+			SELECT role_id FROM role_permissions WHERE permission_id = ?
+			UNION ALL
+			SELECT role_id FROM role_denied_permissions WHERE permission_id = ?
+		) AS grants
+	`, permissionID, permissionID).Scan(&count).Error
+	return count, err
+}
+
+func (r *permissionRepository) CountUserDenials(permissionID uint) (int64, error) {
+	var count int64
+	err := r.db.Table("user_denied_permissions").Where("permission_id = ?", permissionID).Count(&count).Error
+	return count, err
+}
+
+func (r *permissionRepository) GetGrantsByUserID(userID uint) ([]*entities.PermissionGrant, error) {
+	var grants []*entities.PermissionGrant
+
+	query := `
+		WITH RECURSIVE granted_roles (id) AS (
+			SELECT ur.role_id FROM user_roles ur WHERE ur.user_id = ?
+			UNION
+			SELECT rp.parent_id FROM role_parents rp
+			INNER JOIN granted_roles gr ON rp.role_id = gr.id
+		)
+		SELECT p.*, 'allow' AS effect, r.name AS role FROM permissions p
+		INNER JOIN role_permissions rp ON p.id = rp.permission_id
+		INNER JOIN roles r ON rp.role_id = r.id
+		WHERE r.id IN (SELECT id FROM granted_roles)
+		UNION ALL
+		SELECT p.*, 'deny' AS effect, r.name AS role FROM permissions p
+		INNER JOIN role_denied_permissions rd ON p.id = rd.permission_id
+		INNER JOIN roles r ON rd.role_id = r.id
+		WHERE r.id IN (SELECT id FROM granted_roles)
+		UNION ALL
+		SELECT p.*, 'deny' AS effect, '' AS role FROM permissions p
+		INNER JOIN user_denied_permissions ud ON p.id = ud.permission_id
+		WHERE ud.user_id = ?
+	`
+
+	err := r.db.Raw(query, userID, userID).Scan(&grants).Error
+	return grants, err
+}
+
+func (r *permissionRepository) Upsert(permissions []*entities.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	return r.db.Omit("Roles").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "updated_at"}),
+	}).Create(permissions).Error
+}
+
+func (r *permissionRepository) Version() (int64, error) {
+	var version int64
+	err := r.db.Raw("SELECT version FROM rbac_version WHERE id = 1").Scan(&version).Error
+	return version, err
+}
+
+// changeRBAC runs change in a transaction that also increments the RBAC
+// version, so every instance reloads the permission policies it cached.
+func changeRBAC(db *gorm.DB, change func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := change(tx); err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE rbac_version SET version = version + 1 WHERE id = 1").Error
+	})
+}
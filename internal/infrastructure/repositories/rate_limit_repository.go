@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+func NewRateLimitRepository(db *gorm.DB) repositories.RateLimitRepository {
+	return &rateLimitRepository{db: db}
+}
+
+// Take locks the bucket row for the duration of the update, so concurrent
+// requests on different instances cannot overdraw it.
+func (r *rateLimitRepository) Take(key string, rate float64, burst int, now time.Time) (bool, time.Duration, error) {
+	var allowed bool
+	var wait time.Duration
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		bucket := entities.RateLimitBucket{Key: key, Tokens: float64(burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&bucket).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(&bucket).Error; err != nil {
+			return err
+		}
+
+		allowed, wait = takeToken(&bucket.Tokens, &bucket.UpdatedAt, rate, burst, now)
+
+		return tx.Model(&entities.RateLimitBucket{}).Where("key = ?", key).Updates(map[string]interface{}{
+			"tokens":     bucket.Tokens,
+			"updated_at": bucket.UpdatedAt,
+		}).Error
+	})
+
+	return allowed, wait, err
+}
+
+func (r *rateLimitRepository) DeleteStale(before time.Time) (int64, error) {
+	result := r.db.Where("updated_at < ?", before).Delete(&entities.RateLimitBucket{})
+	return result.RowsAffected, result.Error
+}
+
+// takeToken refills a token bucket for the time elapsed since updatedAt and
+// consumes one token if available. Otherwise it reports how long until the
+// next token.
+func takeToken(tokens *float64, updatedAt *time.Time, rate float64, burst int, now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(*updatedAt); elapsed > 0 {
+		*tokens = math.Min(float64(burst), *tokens+elapsed.Seconds()*rate)
+		*updatedAt = now
+	}
+
+	if *tokens < 1 {
+		return false, time.Duration((1 - *tokens) / rate * float64(time.Second))
+	}
+
+	*tokens--
+	return true, 0
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) repositories.RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (r *recoveryCodeRepository) ReplaceForUser(userID uint, codes []*entities.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		for _, code := range codes {
+			code.UserID = userID
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *recoveryCodeRepository) ListUnusedByUserID(userID uint) ([]*entities.RecoveryCode, error) {
+	var codes []*entities.RecoveryCode
+	err := r.db.Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error
+	return codes, err
+}
+
+func (r *recoveryCodeRepository) MarkUsed(id uint) (bool, error) {
+	result := r.db.Model(&entities.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *recoveryCodeRepository) DeleteByUserID(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&entities.RecoveryCode{}).Error
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) repositories.RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(token *entities.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetByJTI(jti string) (*entities.RefreshToken, error) {
+	var token entities.RefreshToken
+	err := r.db.Where("jti = ?", jti).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) MarkConsumed(jti string) (bool, error) {
+	result := r.db.Model(&entities.RefreshToken{}).
+		Where("jti = ? AND consumed_at IS NULL AND revoked_at IS NULL", jti).
+		Update("consumed_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *refreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.db.Model(&entities.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *refreshTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&entities.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
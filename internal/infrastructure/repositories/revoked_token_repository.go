@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRevokedTokenRepository(db *gorm.DB) repositories.RevokedTokenRepository {
+	return &revokedTokenRepository{db: db}
+}
+
+func (r *revokedTokenRepository) Revoke(token *entities.RevokedToken) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "jti"}},
+		DoNothing: true,
+	}).Create(token).Error
+}
+
+func (r *revokedTokenRepository) IsRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *revokedTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&entities.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
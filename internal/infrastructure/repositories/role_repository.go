@@ -3,10 +3,16 @@ package repositories
 import (
 	"auth-system/internal/domain/entities"
 	"auth-system/internal/domain/repositories"
+	"fmt"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// rbacReconcileLock is the Postgres advisory lock key that serializes
+// manifest applies, e.g. by replicas starting together.
+const rbacReconcileLock = 7352004
+
 type roleRepository struct {
 	db *gorm.DB
 }
@@ -16,12 +22,14 @@ func NewRoleRepository(db *gorm.DB) repositories.RoleRepository {
 }
 
 func (r *roleRepository) Create(role *entities.Role) error {
-	return r.db.Create(role).Error
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Create(role).Error
+	})
 }
 
 func (r *roleRepository) GetByID(id uint) (*entities.Role, error) {
 	var role entities.Role
-	err := r.db.Preload("Permissions").First(&role, id).Error
+	err := r.db.Preload("Permissions").Preload("DeniedPermissions").Preload("Parents").First(&role, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -30,23 +38,175 @@ func (r *roleRepository) GetByID(id uint) (*entities.Role, error) {
 
 func (r *roleRepository) GetByName(name string) (*entities.Role, error) {
 	var role entities.Role
-	err := r.db.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	err := r.db.Preload("Permissions").Preload("DeniedPermissions").Preload("Parents").Where("name = ?", name).First(&role).Error
 	if err != nil {
 		return nil, err
 	}
 	return &role, nil
 }
 
+// Update saves the role's own fields. Permissions are changed through
+// AddPermissions and RemovePermission, denials through AddDeniedPermissions
+// and RemoveDeniedPermission, parents through SetParents.
 func (r *roleRepository) Update(role *entities.Role) error {
-	return r.db.Save(role).Error
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Omit("Permissions", "DeniedPermissions", "Parents", "Users").Save(role).Error
+	})
 }
 
 func (r *roleRepository) Delete(id uint) error {
-	return r.db.Delete(&entities.Role{}, id).Error
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_roles WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM role_permissions WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM role_denied_permissions WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM role_parents WHERE role_id = ? OR parent_id = ?", id, id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entities.Role{}, id).Error
+	})
 }
 
 func (r *roleRepository) List() ([]*entities.Role, error) {
 	var roles []*entities.Role
-	err := r.db.Preload("Permissions").Find(&roles).Error
+	err := r.db.Preload("Permissions").Preload("DeniedPermissions").Preload("Parents").Find(&roles).Error
 	return roles, err
 }
+
+func (r *roleRepository) CountUsers(roleID uint) (int64, error) {
+	var count int64
+	err := r.db.Table("user_roles").
+		Joins("INNER JOIN users ON users.id = user_roles.user_id AND users.deleted_at IS NULL").
+		Where("user_roles.role_id = ?", roleID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *roleRepository) AddPermissions(roleID uint, permissions []entities.Permission) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Model(&entities.Role{ID: roleID}).Association("Permissions").Append(permissions)
+	})
+}
+
+func (r *roleRepository) RemovePermission(roleID, permissionID uint) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Model(&entities.Role{ID: roleID}).Association("Permissions").Delete(&entities.Permission{ID: permissionID})
+	})
+}
+
+func (r *roleRepository) AddDeniedPermissions(roleID uint, permissions []entities.Permission) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Model(&entities.Role{ID: roleID}).Association("DeniedPermissions").Append(permissions)
+	})
+}
+
+func (r *roleRepository) RemoveDeniedPermission(roleID, permissionID uint) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Model(&entities.Role{ID: roleID}).Association("DeniedPermissions").Delete(&entities.Permission{ID: permissionID})
+	})
+}
+
+func (r *roleRepository) SetParents(roleID uint, parents []entities.Role) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return replaceAssociation(tx.Model(&entities.Role{ID: roleID}).Association("Parents"), parents)
+	})
+}
+
+func (r *roleRepository) Reconcile(permissions []*entities.Permission, roles []*entities.Role) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rbacReconcileLock).Error; err != nil {
+			return err
+		}
+
+		if err := NewPermissionRepository(tx).Upsert(permissions); err != nil {
+			return err
+		}
+
+		for _, role := range roles {
+			granted, err := findPermissions(tx, role.Permissions)
+			if err != nil {
+				return fmt.Errorf("role %s grants %w", role.Name, err)
+			}
+			denied, err := findPermissions(tx, role.DeniedPermissions)
+			if err != nil {
+				return fmt.Errorf("role %s denies %w", role.Name, err)
+			}
+
+			if err := tx.Omit("Permissions", "DeniedPermissions", "Parents", "Users").Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "name"}},
+				DoUpdates: clause.AssignmentColumns([]string{"description", "updated_at"}),
+			}).Create(role).Error; err != nil {
+				return err
+			}
+
+			if err := replaceAssociation(tx.Model(role).Association("Permissions"), granted); err != nil {
+				return err
+			}
+			if err := replaceAssociation(tx.Model(role).Association("DeniedPermissions"), denied); err != nil {
+				return err
+			}
+			role.Permissions = granted
+			role.DeniedPermissions = denied
+		}
+
+		// Parents may be declared after the roles inheriting from them, so
+		// they are linked once every role exists.
+		for _, role := range roles {
+			names := make([]string, len(role.Parents))
+			for i, parent := range role.Parents {
+				names[i] = parent.Name
+			}
+
+			var parents []entities.Role
+			if len(names) > 0 {
+				if err := tx.Where("name IN ?", names).Find(&parents).Error; err != nil {
+					return err
+				}
+				if len(parents) != len(names) {
+					return fmt.Errorf("role %s inherits from unknown roles", role.Name)
+				}
+			}
+
+			if err := replaceAssociation(tx.Model(role).Association("Parents"), parents); err != nil {
+				return err
+			}
+			role.Parents = parents
+		}
+		return nil
+	})
+}
+
+// findPermissions loads the permissions named in permissions.
+func findPermissions(tx *gorm.DB, permissions []entities.Permission) ([]entities.Permission, error) {
+	if len(permissions) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = permission.Name
+	}
+
+	var found []entities.Permission
+	if err := tx.Where("name IN ?", names).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	if len(found) != len(names) {
+		return nil, fmt.Errorf("unknown permissions")
+	}
+	return found, nil
+}
+
+// replaceAssociation sets the association to exactly values, which GORM's
+// Replace does not do for an empty slice.
+func replaceAssociation[T any](association *gorm.Association, values []T) error {
+	if len(values) == 0 {
+		return association.Clear()
+	}
+	return association.Replace(values)
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type securityEventRepository struct {
+	db *gorm.DB
+}
+
+func NewSecurityEventRepository(db *gorm.DB) repositories.SecurityEventRepository {
+	return &securityEventRepository{db: db}
+}
+
+func (r *securityEventRepository) Create(event *entities.SecurityEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *securityEventRepository) ListByUserID(userID uint, limit int) ([]*entities.SecurityEvent, error) {
+	var events []*entities.SecurityEvent
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
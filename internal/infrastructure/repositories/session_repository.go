@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) repositories.SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *entities.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(id uint) (*entities.Session, error) {
+	var session entities.Session
+	err := r.db.First(&session, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) ListActiveByUserID(userID uint) ([]*entities.Session, error) {
+	var sessions []*entities.Session
+	err := r.db.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) Update(session *entities.Session) error {
+	return r.db.Save(session).Error
+}
+
+func (r *sessionRepository) Revoke(id uint) error {
+	return r.db.Model(&entities.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *sessionRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&entities.Session{})
+	return result.RowsAffected, result.Error
+}
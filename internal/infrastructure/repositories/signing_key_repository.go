@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// signingKeyRotationLock is the Postgres advisory lock key that serializes
+// key rotation across instances.
+const signingKeyRotationLock = 7352001
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) repositories.SigningKeyRepository {
+	return &signingKeyRepository{db: db}
+}
+
+func (r *signingKeyRepository) GetActive() (*entities.SigningKey, error) {
+	var key entities.SigningKey
+	err := r.db.Where("status = ?", entities.SigningKeyStatusActive).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *signingKeyRepository) ListUsable(now time.Time) ([]*entities.SigningKey, error) {
+	var keys []*entities.SigningKey
+	err := r.db.
+		Where("status = ? OR verify_until > ?", entities.SigningKeyStatusActive, now).
+		Order("activated_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *signingKeyRepository) Rotate(newKey *entities.SigningKey, verifyUntil, activatedBefore time.Time) (bool, error) {
+	rotated := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", signingKeyRotationLock).Error; err != nil {
+			return err
+		}
+
+		var current entities.SigningKey
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ?", entities.SigningKeyStatusActive).
+			First(&current).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+		case err != nil:
+			return err
+		case !current.ActivatedAt.Before(activatedBefore):
+			return nil
+		default:
+			now := time.Now()
+			if err := tx.Model(&current).Updates(map[string]any{
+				"status":       entities.SigningKeyStatusRetired,
+				"retired_at":   now,
+				"verify_until": verifyUntil,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		newKey.Status = entities.SigningKeyStatusActive
+		newKey.ActivatedAt = time.Now()
+		if err := tx.Create(newKey).Error; err != nil {
+			return err
+		}
+
+		rotated = true
+		return nil
+	})
+
+	return rotated, err
+}
+
+func (r *signingKeyRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.
+		Where("status = ? AND verify_until < ?", entities.SigningKeyStatusRetired, before).
+		Delete(&entities.SigningKey{})
+	return result.RowsAffected, result.Error
+}
@@ -3,6 +3,9 @@ package repositories
 import (
 	"auth-system/internal/domain/entities"
 	"auth-system/internal/domain/repositories"
+	"slices"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -21,7 +24,7 @@ func (r *userRepository) Create(user *entities.User) error {
 
 func (r *userRepository) GetByID(id uint) (*entities.User, error) {
 	var user entities.User
-	err := r.db.Preload("Roles.Permissions").First(&user, id).Error
+	err := r.db.Preload("Roles.Permissions").Preload("RecoveryCodes", "used_at IS NULL").First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -30,23 +33,230 @@ func (r *userRepository) GetByID(id uint) (*entities.User, error) {
 
 func (r *userRepository) GetByEmail(email string) (*entities.User, error) {
 	var user entities.User
-	err := r.db.Preload("Roles.Permissions").Where("email = ?", email).First(&user).Error
+	err := r.db.Preload("Roles.Permissions").Preload("RecoveryCodes", "used_at IS NULL").Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// Update saves the user and its roles. Recovery codes, denied permissions,
+// login failure tracking and the last used MFA step have their own methods
+// and are never written back from a loaded user.
 func (r *userRepository) Update(user *entities.User) error {
-	return r.db.Save(user).Error
+	return r.db.Omit("RecoveryCodes", "DeniedPermissions", "FailedLoginAttempts", "LastFailedLoginAt", "LockedUntil", "MFALastUsedStep").Save(user).Error
 }
 
 func (r *userRepository) Delete(id uint) error {
 	return r.db.Delete(&entities.User{}, id).Error
 }
 
-func (r *userRepository) List(offset, limit int) ([]*entities.User, error) {
+func (r *userRepository) Restore(id uint) (bool, error) {
+	result := r.db.Unscoped().Model(&entities.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *userRepository) Purge(id uint) (bool, error) {
+	var purged bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{
+			"user_roles",
+			"user_denied_permissions",
+			"recovery_codes",
+			"sessions",
+			"refresh_tokens",
+			"web_authn_credentials",
+			"web_authn_challenges",
+			"password_reset_tokens",
+			"security_events",
+		} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE user_id = ?", id).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Delete(&entities.User{}, id)
+		purged = result.RowsAffected > 0
+		return result.Error
+	})
+	return purged, err
+}
+
+func (r *userRepository) GetDeniedPermissions(userID uint) ([]entities.Permission, error) {
+	var permissions []entities.Permission
+	err := r.db.Model(&entities.User{ID: userID}).Order("name").Association("DeniedPermissions").Find(&permissions)
+	return permissions, err
+}
+
+func (r *userRepository) AddDeniedPermissions(userID uint, permissions []entities.Permission) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Model(&entities.User{ID: userID}).Association("DeniedPermissions").Append(permissions)
+	})
+}
+
+func (r *userRepository) RemoveDeniedPermission(userID, permissionID uint) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Model(&entities.User{ID: userID}).Association("DeniedPermissions").Delete(&entities.Permission{ID: permissionID})
+	})
+}
+
+func (r *userRepository) AddRole(userID, roleID uint) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (?, ?) ON CONFLICT DO NOTHING", userID, roleID).Error
+	})
+}
+
+func (r *userRepository) RemoveRole(userID, roleID uint) error {
+	return changeRBAC(r.db, func(tx *gorm.DB) error {
+		return tx.Exec("DELETE FROM user_roles WHERE user_id = ? AND role_id = ?", userID, roleID).Error
+	})
+}
+
+var userSortColumns = map[string]string{
+	"created_at": "created_at",
+	"email":      "email",
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"id":         "id",
+}
+
+func (r *userRepository) List(filter repositories.UserFilter, offset, limit int) ([]*entities.User, int64, error) {
+	query := r.filterUsers(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := userSortColumns[filter.SortBy]
+	if !ok {
+		column = "created_at"
+	}
+	direction := " ASC"
+	if filter.SortDesc {
+		direction = " DESC"
+	}
+	if column != "id" {
+		query = query.Order(column + direction)
+	}
+	query = query.Order("id" + direction)
+
+	var users []*entities.User
+	err := query.Preload("Roles").Preload("RecoveryCodes", "used_at IS NULL").
+		Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+func (r *userRepository) ListPage(filter repositories.UserFilter, cursor *repositories.UserCursor, backward bool, limit int) ([]*entities.User, error) {
+	query := r.filterUsers(filter)
+
+	// Walking backward reverses the order and the comparison, and the rows
+	// are put back in filter order below.
+	desc := filter.SortDesc != backward
+	direction, comparison := " ASC", ">"
+	if desc {
+		direction, comparison = " DESC", "<"
+	}
+
+	switch filter.SortBy {
+	case "email":
+		if cursor != nil {
+			query = query.Where("(email, id) "+comparison+" (?, ?)", cursor.Email, cursor.ID)
+		}
+		query = query.Order("email" + direction)
+	case "id":
+		if cursor != nil {
+			query = query.Where("id "+comparison+" ?", cursor.ID)
+		}
+	default:
+		if cursor != nil {
+			query = query.Where("(created_at, id) "+comparison+" (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		query = query.Order("created_at" + direction)
+	}
+	query = query.Order("id" + direction)
+
 	var users []*entities.User
-	err := r.db.Preload("Roles").Offset(offset).Limit(limit).Find(&users).Error
-	return users, err
+	err := query.Preload("Roles").Preload("RecoveryCodes", "used_at IS NULL").
+		Limit(limit).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if backward {
+		slices.Reverse(users)
+	}
+	return users, nil
+}
+
+// filterUsers builds the WHERE clause shared by the listings.
+func (r *userRepository) filterUsers(filter repositories.UserFilter) *gorm.DB {
+	query := r.db.Model(&entities.User{})
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		query = query.Where(
+			"email ILIKE ? OR first_name ILIKE ? OR last_name ILIKE ? OR first_name || ' ' || last_name ILIKE ?",
+			pattern, pattern, pattern, pattern,
+		)
+	}
+	if filter.Role != "" {
+		query = query.Where(
+			"id IN (SELECT ur.user_id FROM user_roles ur INNER JOIN roles r ON r.id = ur.role_id WHERE r.name = ?)",
+			filter.Role,
+		)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	return query
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied search term.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func (r *userRepository) RecordLoginFailure(id uint, now, resetBefore time.Time) (int, error) {
+	var attempts int
+	err := r.db.Raw(`
+		UPDATE users SET
+			failed_login_attempts = CASE
+				WHEN last_failed_login_at IS NULL OR last_failed_login_at < ? THEN 1
+				ELSE failed_login_attempts + 1
+			END,
+			last_failed_login_at = ?
+		WHERE id = ?
+		RETURNING failed_login_attempts`, resetBefore, now, id).Scan(&attempts).Error
+	return attempts, err
+}
+
+func (r *userRepository) Lock(id uint, until time.Time) error {
+	return r.db.Model(&entities.User{}).Where("id = ?", id).Update("locked_until", until).Error
+}
+
+func (r *userRepository) UseMFAStep(id uint, step int64) (bool, error) {
+	result := r.db.Model(&entities.User{}).
+		Where("id = ? AND COALESCE(mfa_last_used_step, 0) < ?", id, step).
+		Update("mfa_last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *userRepository) ResetLoginFailures(id uint) error {
+	return r.db.Model(&entities.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"last_failed_login_at":  nil,
+		"locked_until":          nil,
+	}).Error
 }
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"auth-system/internal/domain/entities"
+	"auth-system/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webAuthnCredentialRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnCredentialRepository(db *gorm.DB) repositories.WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepository{db: db}
+}
+
+func (r *webAuthnCredentialRepository) Create(credential *entities.WebAuthnCredential) error {
+	return r.db.Create(credential).Error
+}
+
+func (r *webAuthnCredentialRepository) GetByCredentialID(credentialID string) (*entities.WebAuthnCredential, error) {
+	var credential entities.WebAuthnCredential
+	err := r.db.Where("credential_id = ?", credentialID).First(&credential).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *webAuthnCredentialRepository) ListByUserID(userID uint) ([]*entities.WebAuthnCredential, error) {
+	var credentials []*entities.WebAuthnCredential
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *webAuthnCredentialRepository) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&entities.WebAuthnCredential{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *webAuthnCredentialRepository) UpdateSignCount(id uint, signCount uint32, usedAt time.Time) (bool, error) {
+	query := r.db.Model(&entities.WebAuthnCredential{}).Where("id = ?", id)
+	// Authenticators that do not implement a counter always report zero.
+	if signCount > 0 {
+		query = query.Where("sign_count < ?", signCount)
+	}
+	result := query.Updates(map[string]interface{}{
+		"sign_count":   signCount,
+		"last_used_at": usedAt,
+	})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *webAuthnCredentialRepository) Delete(userID, id uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&entities.WebAuthnCredential{})
+	return result.RowsAffected == 1, result.Error
+}
+
+type webAuthnChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnChallengeRepository(db *gorm.DB) repositories.WebAuthnChallengeRepository {
+	return &webAuthnChallengeRepository{db: db}
+}
+
+func (r *webAuthnChallengeRepository) Create(challenge *entities.WebAuthnChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+func (r *webAuthnChallengeRepository) Consume(challenge string) (*entities.WebAuthnChallenge, error) {
+	var consumed []entities.WebAuthnChallenge
+	result := r.db.Clauses(clause.Returning{}).
+		Where("challenge = ?", challenge).
+		Delete(&consumed)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(consumed) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &consumed[0], nil
+}
+
+func (r *webAuthnChallengeRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&entities.WebAuthnChallenge{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,178 @@
+package security
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// decodeCBOR decodes a single CBOR data item (RFC 8949) and returns it with
+// the number of bytes consumed. It covers what WebAuthn needs: integers,
+// byte and text strings, arrays, maps, tags and simple values. Integers are
+// returned as int64, maps as map[any]any with int64 or string keys.
+func decodeCBOR(data []byte) (any, int, error) {
+	d := &cborDecoder{data: data}
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, d.pos, nil
+}
+
+const cborMaxDepth = 16
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) decode(depth int) (any, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("cbor: nesting too deep")
+	}
+
+	if d.pos >= len(d.data) {
+		return nil, fmt.Errorf("cbor: unexpected end of data")
+	}
+
+	initial := d.data[d.pos]
+	d.pos++
+	major := initial >> 5
+	info := initial & 0x1f
+
+	if major == 7 {
+		return d.decodeSimple(info)
+	}
+
+	arg, err := d.readArgument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer overflow")
+		}
+		return int64(arg), nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer overflow")
+		}
+		return -1 - int64(arg), nil
+	case 2, 3:
+		bytes, err := d.readBytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		if major == 3 {
+			return string(bytes), nil
+		}
+		return bytes, nil
+	case 4:
+		if arg > uint64(len(d.data)) {
+			return nil, fmt.Errorf("cbor: array too long")
+		}
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case 5:
+		if arg > uint64(len(d.data)) {
+			return nil, fmt.Errorf("cbor: map too long")
+		}
+		m := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	case 6:
+		// Tags carry no meaning for WebAuthn payloads; return the content.
+		return d.decode(depth + 1)
+	}
+
+	return nil, fmt.Errorf("cbor: unsupported major type %d", major)
+}
+
+func (d *cborDecoder) readArgument(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		b, err := d.readBytes(1)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(b[0]), nil
+	case info == 25:
+		b, err := d.readBytes(2)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case info == 26:
+		b, err := d.readBytes(4)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	case info == 27:
+		b, err := d.readBytes(8)
+		if err != nil {
+			return 0, err
+		}
+		return binary.BigEndian.Uint64(b), nil
+	}
+	return 0, fmt.Errorf("cbor: indefinite lengths are not supported")
+}
+
+func (d *cborDecoder) decodeSimple(info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 26:
+		b, err := d.readBytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 27:
+		b, err := d.readBytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	}
+	return nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+}
+
+func (d *cborDecoder) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, fmt.Errorf("cbor: unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
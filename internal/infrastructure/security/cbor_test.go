@@ -0,0 +1,177 @@
+package security
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	// Examples from RFC 8949, Appendix A.
+	tests := []struct {
+		name string
+		hex  string
+		want any
+	}{
+		{"zero", "00", int64(0)},
+		{"small integer", "17", int64(23)},
+		{"one byte integer", "1818", int64(24)},
+		{"two byte integer", "1903e8", int64(1000)},
+		{"four byte integer", "1a000f4240", int64(1000000)},
+		{"eight byte integer", "1b000000e8d4a51000", int64(1000000000000)},
+		{"negative one", "20", int64(-1)},
+		{"negative integer", "3903e7", int64(-1000)},
+		{"byte string", "4401020304", []byte{1, 2, 3, 4}},
+		{"text string", "6449455446", "IETF"},
+		{"empty array", "80", []any{}},
+		{"array", "83010203", []any{int64(1), int64(2), int64(3)}},
+		{"nested array", "8301820203820405", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"map with integer keys", "a201020304", map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+		{"map with text keys", "a26161016162820203", map[any]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"false", "f4", false},
+		{"true", "f5", true},
+		{"null", "f6", nil},
+		{"single precision float", "fa47c35000", float64(100000)},
+		{"double precision float", "fb3ff199999999999a", 1.1},
+		{"tag", "c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := mustDecodeHex(t, tt.hex)
+			got, n, err := decodeCBOR(data)
+			if err != nil {
+				t.Fatalf("decodeCBOR(%s) error = %v", tt.hex, err)
+			}
+			if n != len(data) {
+				t.Errorf("decodeCBOR(%s) consumed %d bytes, want %d", tt.hex, n, len(data))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCBOR(%s) = %#v, want %#v", tt.hex, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORConsumesOneItem(t *testing.T) {
+	got, n, err := decodeCBOR([]byte{0x01, 0x02})
+	if err != nil {
+		t.Fatalf("decodeCBOR error = %v", err)
+	}
+	if got != int64(1) || n != 1 {
+		t.Errorf("decodeCBOR = %v, %d; want 1, 1", got, n)
+	}
+}
+
+func TestDecodeCBORErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		hex  string
+	}{
+		{"empty", ""},
+		{"truncated argument", "1903"},
+		{"truncated byte string", "4401"},
+		{"truncated array", "8301"},
+		{"indefinite length", "5f"},
+		{"integer overflow", "1bffffffffffffffff"},
+		{"negative integer overflow", "3bffffffffffffffff"},
+		{"array longer than data", "9b00000000ffffffff"},
+		{"byte string map key", "a14100f6"},
+		{"unsupported simple value", "f0"},
+		{"nesting too deep", strings.Repeat("81", cborMaxDepth+2) + "00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _, err := decodeCBOR(mustDecodeHex(t, tt.hex)); err == nil {
+				t.Errorf("decodeCBOR(%s) = %#v, want an error", tt.hex, got)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORRoundTrip(t *testing.T) {
+	want := map[any]any{
+		int64(1):  int64(2),
+		int64(3):  int64(COSEAlgRS256),
+		int64(-1): []byte(strings.Repeat("n", 256)),
+		"fmt":     "none",
+		"attStmt": map[any]any{},
+		"list":    []any{int64(70000), int64(-70000), "x"},
+	}
+
+	got, _, err := decodeCBOR(encodeCBOR(want))
+	if err != nil {
+		t.Fatalf("decodeCBOR error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeCBOR = %#v, want %#v", got, want)
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// encodeCBOR encodes the values decodeCBOR returns, for building
+// authenticator payloads in tests. Map keys are sorted so the output is
+// deterministic.
+func encodeCBOR(value any) []byte {
+	switch v := value.(type) {
+	case int:
+		return encodeCBOR(int64(v))
+	case int64:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case []any:
+		out := cborHead(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case map[any]any:
+		keys := make([][]byte, 0, len(v))
+		items := make(map[string][]byte, len(v))
+		for key, item := range v {
+			encoded := encodeCBOR(key)
+			keys = append(keys, encoded)
+			items[string(encoded)] = encodeCBOR(item)
+		}
+		sort.Slice(keys, func(i, j int) bool { return string(keys[i]) < string(keys[j]) })
+		out := cborHead(5, uint64(len(v)))
+		for _, key := range keys {
+			out = append(out, key...)
+			out = append(out, items[string(key)]...)
+		}
+		return out
+	}
+	panic("encodeCBOR: unsupported type")
+}
+
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, arg)
+}
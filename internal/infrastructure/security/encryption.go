@@ -0,0 +1,56 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// Encrypter protects secrets stored in the database with AES-256-GCM. The
+// key is derived from a configured secret.
+type Encrypter struct {
+	aead cipher.AEAD
+}
+
+func NewEncrypter(secret string) (*Encrypter, error) {
+	key := sha256.Sum256([]byte(secret))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return &Encrypter{aead: aead}, nil
+}
+
+func (e *Encrypter) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := e.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (e *Encrypter) Decrypt(ciphertext string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+
+	nonceSize := e.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	return e.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+}
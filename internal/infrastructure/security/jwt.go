@@ -1,7 +1,10 @@
 package security
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -9,19 +12,20 @@ import (
 )
 
 type JWTManager struct {
-	secretKey       string
+	keyring         *Keyring
 	accessTokenTTL  time.Duration
 	refreshTokenTTL time.Duration
 }
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
-	Type   string `json:"type"` // "access" or "refresh"
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	Type      string `json:"type"` // "access", "refresh", "mfa" or "email_verification"
+	SessionID uint   `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTManager(secret, accessTTL, refreshTTL string) (*JWTManager, error) {
+func NewJWTManager(keyring *Keyring, accessTTL, refreshTTL string) (*JWTManager, error) {
 	accessDuration, err := time.ParseDuration(accessTTL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid access token TTL: %w", err)
@@ -33,48 +37,102 @@ func NewJWTManager(secret, accessTTL, refreshTTL string) (*JWTManager, error) {
 	}
 
 	return &JWTManager{
-		secretKey:       secret,
+		keyring:         keyring,
 		accessTokenTTL:  accessDuration,
 		refreshTokenTTL: refreshDuration,
 	}, nil
 }
 
-func (j *JWTManager) GenerateTokenPair(userID uint, email string) (string, string, error) {
-	accessToken, err := j.generateToken(userID, email, "access", j.accessTokenTTL)
+type TokenPair struct {
+	AccessToken   string
+	RefreshToken  string
+	AccessClaims  *Claims
+	RefreshClaims *Claims
+}
+
+func (j *JWTManager) AccessTokenTTL() time.Duration {
+	return j.accessTokenTTL
+}
+
+func (j *JWTManager) RefreshTokenTTL() time.Duration {
+	return j.refreshTokenTTL
+}
+
+func (j *JWTManager) GenerateTokenPair(userID uint, email string, sessionID uint) (*TokenPair, error) {
+	accessToken, accessClaims, err := j.generateToken(userID, email, sessionID, "access", j.accessTokenTTL)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	refreshToken, err := j.generateToken(userID, email, "refresh", j.refreshTokenTTL)
+	refreshToken, refreshClaims, err := j.generateToken(userID, email, sessionID, "refresh", j.refreshTokenTTL)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	return accessToken, refreshToken, nil
+	return &TokenPair{
+		AccessToken:   accessToken,
+		RefreshToken:  refreshToken,
+		AccessClaims:  accessClaims,
+		RefreshClaims: refreshClaims,
+	}, nil
+}
+
+// GenerateMFAToken issues the short-lived challenge token returned by login
+// when a second factor is required. It cannot be used as an access token.
+func (j *JWTManager) GenerateMFAToken(userID uint, email string, ttl time.Duration) (string, *Claims, error) {
+	return j.generateToken(userID, email, 0, "mfa", ttl)
 }
 
-func (j *JWTManager) generateToken(userID uint, email, tokenType string, ttl time.Duration) (string, error) {
+// GenerateEmailVerificationToken issues the token mailed to a user to prove
+// ownership of email. It is only valid while the account keeps that address.
+func (j *JWTManager) GenerateEmailVerificationToken(userID uint, email string, ttl time.Duration) (string, *Claims, error) {
+	return j.generateToken(userID, email, 0, "email_verification", ttl)
+}
+
+func (j *JWTManager) generateToken(userID uint, email string, sessionID uint, tokenType string, ttl time.Duration) (string, *Claims, error) {
+	tokenID, err := GenerateTokenID()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token id: %w", err)
+	}
+
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Type:   tokenType,
+		UserID:    userID,
+		Email:     email,
+		Type:      tokenType,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   strconv.FormatUint(uint64(userID), 10),
+			ID:        tokenID,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	key := j.keyring.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.signingKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
 func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		// Tokens issued before kid headers were introduced carry none.
+		key := j.keyring.Active()
+		if kid, _ := t.Header["kid"].(string); kid != "" {
+			var err error
+			if key, err = j.keyring.Get(kid); err != nil {
+				return nil, err
+			}
+		}
+
+		if t.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(j.secretKey), nil
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
@@ -88,3 +146,32 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// JWKS returns the public keys that verify tokens issued by this manager.
+// Symmetric keys are never included.
+func (j *JWTManager) JWKS() (*JWKSet, error) {
+	set := &JWKSet{Keys: []JWK{}}
+	for _, key := range j.keyring.Keys() {
+		if key.IsSymmetric() {
+			continue
+		}
+
+		jwk, err := key.PublicJWK()
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, *jwk)
+	}
+
+	sort.Slice(set.Keys, func(a, b int) bool { return set.Keys[a].KeyID < set.Keys[b].KeyID })
+	return set, nil
+}
+
+// GenerateTokenID returns a random 128-bit identifier encoded as hex.
+func GenerateTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
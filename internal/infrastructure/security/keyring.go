@@ -0,0 +1,101 @@
+package security
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Keyring holds the key used to sign new tokens together with older keys
+// that are still accepted for verification.
+type Keyring struct {
+	mu     sync.RWMutex
+	active *SigningKey
+	keys   map[string]*SigningKey
+
+	refresh         func() error
+	refreshInterval time.Duration
+	lastRefresh     time.Time
+	refreshMu       sync.Mutex
+}
+
+func NewKeyring(active *SigningKey, verificationKeys ...*SigningKey) *Keyring {
+	k := &Keyring{}
+	k.Replace(active, verificationKeys...)
+	return k
+}
+
+// Replace swaps the whole key set atomically. The active key is always
+// included in the verification set.
+func (k *Keyring) Replace(active *SigningKey, verificationKeys ...*SigningKey) {
+	keys := make(map[string]*SigningKey, len(verificationKeys)+1)
+	for _, key := range verificationKeys {
+		keys[key.ID] = key
+	}
+	keys[active.ID] = active
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.active = active
+	k.keys = keys
+}
+
+// SetRefresher registers a function that reloads the keyring. It is called
+// when a token references an unknown kid, at most once per interval, so a
+// key activated by another instance is picked up without waiting for the
+// next scheduled sync.
+func (k *Keyring) SetRefresher(refresh func() error, interval time.Duration) {
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+	k.refresh = refresh
+	k.refreshInterval = interval
+}
+
+func (k *Keyring) Active() *SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+func (k *Keyring) Get(kid string) (*SigningKey, error) {
+	if key := k.lookup(kid); key != nil {
+		return key, nil
+	}
+
+	if k.tryRefresh() {
+		if key := k.lookup(kid); key != nil {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signing key: %s", kid)
+}
+
+func (k *Keyring) Keys() []*SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]*SigningKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (k *Keyring) lookup(kid string) *SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[kid]
+}
+
+func (k *Keyring) tryRefresh() bool {
+	k.refreshMu.Lock()
+	defer k.refreshMu.Unlock()
+
+	if k.refresh == nil || time.Since(k.lastRefresh) < k.refreshInterval {
+		return false
+	}
+
+	k.lastRefresh = time.Now()
+	return k.refresh() == nil
+}
@@ -0,0 +1,297 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const hmacPEMType = "HMAC KEY"
+
+// SigningKey is a key used to sign and verify tokens. For HMAC keys the
+// signing and verification keys are the same secret; for asymmetric keys
+// only the public half is ever published.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	signingKey any
+	verifyKey  any
+}
+
+func NewHMACSigningKey(id string, secret []byte) *SigningKey {
+	if id == "" {
+		sum := sha256.Sum256(secret)
+		id = "hs-" + base64.RawURLEncoding.EncodeToString(sum[:8])
+	}
+
+	return &SigningKey{
+		ID:         id,
+		Method:     jwt.SigningMethodHS256,
+		signingKey: secret,
+		verifyKey:  secret,
+	}
+}
+
+// LoadSigningKeyFile reads a PEM encoded RSA, ECDSA or Ed25519 private key.
+// See ParseSigningKeyPEM for the meaning of id and alg.
+func LoadSigningKeyFile(id, path, alg string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	return ParseSigningKeyPEM(id, data, alg)
+}
+
+// ParseSigningKeyPEM parses a PKCS#8, PKCS#1 or SEC 1 private key. When alg
+// is empty it is derived from the key type (RS256, ES256/384/512 by curve, or
+// EdDSA). When id is empty the RFC 7638 thumbprint of the public key is used,
+// so every instance loading the same key agrees on its kid.
+func ParseSigningKeyPEM(id string, data []byte, alg string) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in signing key")
+	}
+
+	var privateKey any
+	var err error
+	switch block.Type {
+	case hmacPEMType:
+		return NewHMACSigningKey(id, block.Bytes), nil
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	return NewAsymmetricSigningKey(id, privateKey, alg)
+}
+
+func NewAsymmetricSigningKey(id string, privateKey any, alg string) (*SigningKey, error) {
+	var publicKey crypto.PublicKey
+	var method jwt.SigningMethod
+
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		publicKey = &key.PublicKey
+		if alg == "" {
+			alg = "RS256"
+		}
+		switch alg {
+		case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+			method = jwt.GetSigningMethod(alg)
+		}
+	case *ecdsa.PrivateKey:
+		publicKey = &key.PublicKey
+		curveAlg := map[elliptic.Curve]string{
+			elliptic.P256(): "ES256",
+			elliptic.P384(): "ES384",
+			elliptic.P521(): "ES512",
+		}[key.Curve]
+		if alg == "" {
+			alg = curveAlg
+		}
+		if alg != "" && alg == curveAlg {
+			method = jwt.GetSigningMethod(alg)
+		}
+	case ed25519.PrivateKey:
+		publicKey = key.Public()
+		if alg == "" {
+			alg = "EdDSA"
+		}
+		if alg == "EdDSA" {
+			method = jwt.SigningMethodEdDSA
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+
+	if method == nil {
+		return nil, fmt.Errorf("algorithm %q cannot be used with a %T key", alg, privateKey)
+	}
+
+	key := &SigningKey{
+		ID:         id,
+		Method:     method,
+		signingKey: privateKey,
+		verifyKey:  publicKey,
+	}
+
+	if key.ID == "" {
+		jwk, err := key.PublicJWK()
+		if err != nil {
+			return nil, err
+		}
+		key.ID, err = jwk.Thumbprint()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// GenerateSigningKey creates a fresh key for the given algorithm.
+func GenerateSigningKey(id, alg string) (*SigningKey, error) {
+	var privateKey any
+	var err error
+
+	switch alg {
+	case "HS256":
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		if id == "" {
+			if id, err = GenerateTokenID(); err != nil {
+				return nil, err
+			}
+		}
+		return NewHMACSigningKey(id, secret), nil
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		privateKey, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		privateKey, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "EdDSA":
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key: %w", alg, err)
+	}
+
+	return NewAsymmetricSigningKey(id, privateKey, alg)
+}
+
+// EncodePEM serializes the private key material so it can be stored and
+// later restored with ParseSigningKeyPEM.
+func (k *SigningKey) EncodePEM() ([]byte, error) {
+	if secret, ok := k.signingKey.([]byte); ok {
+		return pem.EncodeToMemory(&pem.Block{Type: hmacPEMType, Bytes: secret}), nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(k.signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// IsSymmetric reports whether the key is a shared secret, which must never
+// be published.
+func (k *SigningKey) IsSymmetric() bool {
+	_, ok := k.Method.(*jwt.SigningMethodHMAC)
+	return ok
+}
+
+// JWK is a JSON Web Key as defined by RFC 7517, limited to public keys.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k *SigningKey) PublicJWK() (*JWK, error) {
+	jwk := &JWK{
+		KeyID:     k.ID,
+		Use:       "sig",
+		Algorithm: k.Method.Alg(),
+	}
+
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode EC public key: %w", err)
+		}
+		// Uncompressed point: 0x04 || X || Y
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		jwk.KeyType = "EC"
+		jwk.Curve = pub.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return nil, fmt.Errorf("key %s has no public representation", k.ID)
+	}
+
+	return jwk, nil
+}
+
+// Thumbprint computes the RFC 7638 JWK thumbprint.
+func (j *JWK) Thumbprint() (string, error) {
+	var members any
+	switch j.KeyType {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.KeyType, j.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Curve, j.KeyType, j.X, j.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Curve, j.KeyType, j.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %q", j.KeyType)
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
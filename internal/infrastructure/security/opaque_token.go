@@ -0,0 +1,26 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateOpaqueToken returns a random 256-bit token for links sent to users.
+// Only its HashOpaqueToken digest should be stored.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashOpaqueToken returns the hex SHA-256 digest of token. A fast hash is
+// enough because the tokens carry 256 bits of entropy, and it lets the
+// digest be looked up directly.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,29 @@
+package security
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// GenerateRecoveryCode returns a random 50-bit code formatted as
+// "xxxxx-xxxxx" for readability.
+func GenerateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := recoveryCodeEncoding.EncodeToString(b)[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// NormalizeRecoveryCode strips separators and case so users can type codes
+// the way they like.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
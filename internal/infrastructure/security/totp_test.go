@@ -0,0 +1,180 @@
+package security
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of RFC 6238, Appendix B, the ASCII string
+// "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPRFC6238Vectors(t *testing.T) {
+	// The appendix lists 8-digit codes; 6-digit codes are their last six
+	// digits.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	manager := NewTOTPManager("Example")
+	for _, tt := range tests {
+		now := time.Unix(tt.unix, 0)
+
+		code, err := manager.GenerateCode(rfc6238Secret, now)
+		if err != nil {
+			t.Fatalf("GenerateCode error = %v", err)
+		}
+		if code != tt.code {
+			t.Errorf("GenerateCode at %d = %s, want %s", tt.unix, code, tt.code)
+		}
+
+		step, ok := manager.Validate(rfc6238Secret, tt.code, now)
+		if !ok || step != tt.unix/totpPeriod {
+			t.Errorf("Validate(%s) at %d = %d, %v; want %d, true", tt.code, tt.unix, step, ok, tt.unix/totpPeriod)
+		}
+	}
+}
+
+func TestTOTPValidateWindow(t *testing.T) {
+	manager := NewTOTPManager("Example")
+	// 1111111111 is the middle of step 37037037.
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name   string
+		offset int64
+		wantOK bool
+	}{
+		{"two steps early", -2, false},
+		{"one step early", -1, true},
+		{"current step", 0, true},
+		{"one step late", 1, true},
+		{"two steps late", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := manager.GenerateCode(rfc6238Secret, now.Add(time.Duration(tt.offset*totpPeriod)*time.Second))
+			if err != nil {
+				t.Fatalf("GenerateCode error = %v", err)
+			}
+
+			step, ok := manager.Validate(rfc6238Secret, code, now)
+			if ok != tt.wantOK {
+				t.Fatalf("Validate = %v, want %v", ok, tt.wantOK)
+			}
+			// The matched step is returned so callers can reject replays.
+			if ok && step != current+tt.offset {
+				t.Errorf("Validate step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestTOTPValidateRejectsMalformedInput(t *testing.T) {
+	manager := NewTOTPManager("Example")
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"short code", rfc6238Secret, "28708"},
+		{"long code", rfc6238Secret, "2870820"},
+		{"eight digit code", rfc6238Secret, "94287082"},
+		{"wrong code", rfc6238Secret, "287083"},
+		{"invalid secret", "not base32!", "287082"},
+		{"other secret", "JBSWY3DPEHPK3PXP", "287082"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := manager.Validate(tt.secret, tt.code, now); ok {
+				t.Errorf("Validate(%q, %q) = true, want false", tt.secret, tt.code)
+			}
+		})
+	}
+}
+
+func TestTOTPGenerateSecret(t *testing.T) {
+	manager := NewTOTPManager("Example")
+
+	secret, err := manager.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret error = %v", err)
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("GenerateSecret = %q, not unpadded base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("GenerateSecret key length = %d, want 20", len(key))
+	}
+
+	now := time.Now()
+	code, err := manager.GenerateCode(secret, now)
+	if err != nil {
+		t.Fatalf("GenerateCode error = %v", err)
+	}
+	if _, ok := manager.Validate(secret, code, now); !ok {
+		t.Error("Validate rejected a code of a generated secret")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(NewTOTPManager("Example Co").ProvisioningURI("user@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatalf("ProvisioningURI is not a URL: %v", err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("ProvisioningURI = %s, want otpauth://totp/...", uri)
+	}
+	if label := strings.TrimPrefix(uri.Path, "/"); label != "Example Co:user@example.com" {
+		t.Errorf("ProvisioningURI label = %q", label)
+	}
+
+	want := map[string]string{
+		"secret":    rfc6238Secret,
+		"issuer":    "Example Co",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	query := uri.Query()
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Errorf("ProvisioningURI %s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	code, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCode error = %v", err)
+	}
+	if len(code) != 11 || code[5] != '-' {
+		t.Fatalf("GenerateRecoveryCode = %q, want xxxxx-xxxxx", code)
+	}
+
+	normalized := NormalizeRecoveryCode(code)
+	for _, typed := range []string{code, strings.ToUpper(code), code[:5] + " " + code[6:], code[:5] + code[6:]} {
+		if got := NormalizeRecoveryCode(typed); got != normalized {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", typed, got, normalized)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+func testRelyingParty() *WebAuthnRelyingParty {
+	return NewWebAuthnRelyingParty(testRPID, "Example", []string{testOrigin})
+}
+
+// testAuthenticator is an in-memory authenticator holding one credential.
+type testAuthenticator struct {
+	credentialID []byte
+	coseKey      []byte
+	algorithm    int
+	sign         func(data []byte) []byte
+}
+
+func newTestAuthenticator(t *testing.T, algorithm int) *testAuthenticator {
+	t.Helper()
+
+	a := &testAuthenticator{
+		credentialID: []byte("credential-" + t.Name()),
+		algorithm:    algorithm,
+	}
+	switch algorithm {
+	case COSEAlgES256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.coseKey = encodeCBOR(map[any]any{
+			1: 2, 3: COSEAlgES256, -1: 1,
+			-2: key.X.FillBytes(make([]byte, 32)),
+			-3: key.Y.FillBytes(make([]byte, 32)),
+		})
+		a.sign = func(data []byte) []byte {
+			digest := sha256.Sum256(data)
+			signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signature
+		}
+	case COSEAlgEdDSA:
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.coseKey = encodeCBOR(map[any]any{1: 1, 3: COSEAlgEdDSA, -1: 6, -2: []byte(publicKey)})
+		a.sign = func(data []byte) []byte {
+			return ed25519.Sign(privateKey, data)
+		}
+	case COSEAlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.coseKey = encodeCBOR(map[any]any{
+			1: 3, 3: COSEAlgRS256,
+			-1: key.N.Bytes(),
+			-2: big.NewInt(int64(key.E)).Bytes(),
+		})
+		a.sign = func(data []byte) []byte {
+			digest := sha256.Sum256(data)
+			signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signature
+		}
+	default:
+		t.Fatalf("unsupported algorithm %d", algorithm)
+	}
+	return a
+}
+
+func (a *testAuthenticator) authData(rpID string, flags byte, signCount uint32, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey...)
+	}
+	return data
+}
+
+func (a *testAuthenticator) attestationObject(rpID string, flags byte) []byte {
+	return encodeCBOR(map[any]any{
+		"fmt":      "none",
+		"attStmt":  map[any]any{},
+		"authData": a.authData(rpID, flags|authDataFlagAttestedCreds, 0, true),
+	})
+}
+
+// assertion returns the authenticator data and its signature over it and
+// the client data.
+func (a *testAuthenticator) assertion(rpID string, flags byte, signCount uint32, clientDataJSON []byte) ([]byte, []byte) {
+	authData := a.authData(rpID, flags, signCount, false)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	return authData, a.sign(append(append([]byte{}, authData...), clientDataHash[:]...))
+}
+
+func testClientData(t *testing.T, ceremony, challenge, origin string) []byte {
+	t.Helper()
+	data, err := json.Marshal(CollectedClientData{Type: ceremony, Challenge: challenge, Origin: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func testChallenge(t *testing.T) string {
+	t.Helper()
+	challenge, err := NewWebAuthnChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return challenge
+}
+
+var testAlgorithms = []struct {
+	name      string
+	algorithm int
+}{
+	{"ES256", COSEAlgES256},
+	{"EdDSA", COSEAlgEdDSA},
+	{"RS256", COSEAlgRS256},
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	rp := testRelyingParty()
+	for _, alg := range testAlgorithms {
+		t.Run(alg.name, func(t *testing.T) {
+			authenticator := newTestAuthenticator(t, alg.algorithm)
+			challenge := testChallenge(t)
+
+			registration, err := rp.VerifyRegistration(
+				testClientData(t, "webauthn.create", challenge, testOrigin),
+				authenticator.attestationObject(testRPID, authDataFlagUserPresent|authDataFlagUserVerified),
+				challenge,
+				true,
+			)
+			if err != nil {
+				t.Fatalf("VerifyRegistration error = %v", err)
+			}
+
+			if string(registration.CredentialID) != string(authenticator.credentialID) {
+				t.Errorf("CredentialID = %q, want %q", registration.CredentialID, authenticator.credentialID)
+			}
+			if string(registration.PublicKey) != string(authenticator.coseKey) {
+				t.Errorf("PublicKey = %x, want %x", registration.PublicKey, authenticator.coseKey)
+			}
+			if registration.Algorithm != alg.algorithm {
+				t.Errorf("Algorithm = %d, want %d", registration.Algorithm, alg.algorithm)
+			}
+			if !registration.UserVerified {
+				t.Error("UserVerified = false, want true")
+			}
+		})
+	}
+}
+
+func TestVerifyRegistrationRejects(t *testing.T) {
+	rp := testRelyingParty()
+	authenticator := newTestAuthenticator(t, COSEAlgES256)
+	challenge := testChallenge(t)
+	flags := byte(authDataFlagUserPresent)
+
+	tests := []struct {
+		name              string
+		clientDataJSON    []byte
+		attestationObject []byte
+		requireUV         bool
+	}{
+		{
+			name:              "wrong origin",
+			clientDataJSON:    testClientData(t, "webauthn.create", challenge, "https://evil.example"),
+			attestationObject: authenticator.attestationObject(testRPID, flags),
+		},
+		{
+			name:              "wrong RP ID hash",
+			clientDataJSON:    testClientData(t, "webauthn.create", challenge, testOrigin),
+			attestationObject: authenticator.attestationObject("evil.example", flags),
+		},
+		{
+			name:              "other challenge",
+			clientDataJSON:    testClientData(t, "webauthn.create", testChallenge(t), testOrigin),
+			attestationObject: authenticator.attestationObject(testRPID, flags),
+		},
+		{
+			name:              "authentication client data",
+			clientDataJSON:    testClientData(t, "webauthn.get", challenge, testOrigin),
+			attestationObject: authenticator.attestationObject(testRPID, flags),
+		},
+		{
+			name:              "user not present",
+			clientDataJSON:    testClientData(t, "webauthn.create", challenge, testOrigin),
+			attestationObject: authenticator.attestationObject(testRPID, 0),
+		},
+		{
+			name:              "user not verified",
+			clientDataJSON:    testClientData(t, "webauthn.create", challenge, testOrigin),
+			attestationObject: authenticator.attestationObject(testRPID, flags),
+			requireUV:         true,
+		},
+		{
+			name:           "no attested credential",
+			clientDataJSON: testClientData(t, "webauthn.create", challenge, testOrigin),
+			attestationObject: encodeCBOR(map[any]any{
+				"fmt":      "none",
+				"authData": authenticator.authData(testRPID, flags, 0, false),
+			}),
+		},
+		{
+			name:              "malformed attestation object",
+			clientDataJSON:    testClientData(t, "webauthn.create", challenge, testOrigin),
+			attestationObject: []byte{0xa1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := rp.VerifyRegistration(tt.clientDataJSON, tt.attestationObject, challenge, tt.requireUV); err == nil {
+				t.Error("VerifyRegistration succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestVerifyRegistrationRejectsUnsupportedAlgorithm(t *testing.T) {
+	authenticator := newTestAuthenticator(t, COSEAlgES256)
+	// ES384, which is not offered in the creation options.
+	authenticator.coseKey = encodeCBOR(map[any]any{1: 2, 3: -35, -1: 2})
+	challenge := testChallenge(t)
+
+	_, err := testRelyingParty().VerifyRegistration(
+		testClientData(t, "webauthn.create", challenge, testOrigin),
+		authenticator.attestationObject(testRPID, authDataFlagUserPresent),
+		challenge,
+		false,
+	)
+	if err == nil {
+		t.Error("VerifyRegistration succeeded, want an error")
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	rp := testRelyingParty()
+	for _, alg := range testAlgorithms {
+		t.Run(alg.name, func(t *testing.T) {
+			authenticator := newTestAuthenticator(t, alg.algorithm)
+			challenge := testChallenge(t)
+			clientDataJSON := testClientData(t, "webauthn.get", challenge, testOrigin)
+			authData, signature := authenticator.assertion(testRPID, authDataFlagUserPresent, 42, clientDataJSON)
+
+			result, err := rp.VerifyAssertion(clientDataJSON, authData, signature, challenge, authenticator.coseKey, false)
+			if err != nil {
+				t.Fatalf("VerifyAssertion error = %v", err)
+			}
+			if result.SignCount != 42 {
+				t.Errorf("SignCount = %d, want 42", result.SignCount)
+			}
+			if result.UserVerified {
+				t.Error("UserVerified = true, want false")
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionRejects(t *testing.T) {
+	rp := testRelyingParty()
+	authenticator := newTestAuthenticator(t, COSEAlgES256)
+	other := newTestAuthenticator(t, COSEAlgES256)
+	challenge := testChallenge(t)
+	flags := byte(authDataFlagUserPresent)
+
+	valid := testClientData(t, "webauthn.get", challenge, testOrigin)
+	validAuthData, validSignature := authenticator.assertion(testRPID, flags, 1, valid)
+
+	type assertion struct {
+		clientDataJSON, authData, signature []byte
+	}
+	sign := func(clientDataJSON []byte, rpID string, flags byte) assertion {
+		authData, signature := authenticator.assertion(rpID, flags, 1, clientDataJSON)
+		return assertion{clientDataJSON, authData, signature}
+	}
+
+	tests := []struct {
+		name      string
+		assertion assertion
+		requireUV bool
+	}{
+		{
+			name:      "wrong origin",
+			assertion: sign(testClientData(t, "webauthn.get", challenge, "https://evil.example"), testRPID, flags),
+		},
+		{
+			name:      "wrong RP ID hash",
+			assertion: sign(valid, "evil.example", flags),
+		},
+		{
+			// An assertion captured for an earlier challenge and replayed
+			// against the current one.
+			name:      "replayed challenge",
+			assertion: sign(testClientData(t, "webauthn.get", testChallenge(t), testOrigin), testRPID, flags),
+		},
+		{
+			name:      "registration client data",
+			assertion: sign(testClientData(t, "webauthn.create", challenge, testOrigin), testRPID, flags),
+		},
+		{
+			name:      "user not present",
+			assertion: sign(valid, testRPID, 0),
+		},
+		{
+			name:      "user not verified",
+			assertion: sign(valid, testRPID, flags),
+			requireUV: true,
+		},
+		{
+			name: "signed by another key",
+			assertion: func() assertion {
+				authData, signature := other.assertion(testRPID, flags, 1, valid)
+				return assertion{valid, authData, signature}
+			}(),
+		},
+		{
+			name: "sign count changed after signing",
+			assertion: func() assertion {
+				authData := append([]byte{}, validAuthData...)
+				binary.BigEndian.PutUint32(authData[33:37], 1000)
+				return assertion{valid, authData, validSignature}
+			}(),
+		},
+		{
+			name:      "client data changed after signing",
+			assertion: assertion{append(append([]byte{}, valid...), ' '), validAuthData, validSignature},
+		},
+		{
+			name:      "truncated authenticator data",
+			assertion: assertion{valid, validAuthData[:36], validSignature},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.assertion
+			if _, err := rp.VerifyAssertion(a.clientDataJSON, a.authData, a.signature, challenge, authenticator.coseKey, tt.requireUV); err == nil {
+				t.Error("VerifyAssertion succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestDecodeWebAuthnBase64(t *testing.T) {
+	for _, value := range []string{"aGk", "aGk=", "-_8", "-_8="} {
+		if _, err := DecodeWebAuthnBase64(value); err != nil {
+			t.Errorf("DecodeWebAuthnBase64(%q) error = %v", value, err)
+		}
+	}
+	if _, err := DecodeWebAuthnBase64("+/8="); err == nil {
+		t.Error("DecodeWebAuthnBase64 accepted standard base64")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"auth-system/internal/application/dto"
 	"auth-system/internal/domain/services"
 	"auth-system/pkg/utils"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,6 +25,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		utils.ValidationErrorResponse(c, "Invalid request data")
 		return
 	}
+	req.ClientInfo = clientInfo(c)
 
 	response, err := h.authService.Login(&req)
 	if err != nil {
@@ -31,6 +33,28 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if response.MFARequired {
+		utils.SuccessResponse(c, "MFA verification required", response)
+		return
+	}
+
+	utils.SuccessResponse(c, "Login successful", response)
+}
+
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req dto.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+	req.ClientInfo = clientInfo(c)
+
+	response, err := h.authService.VerifyMFA(&req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
 	utils.SuccessResponse(c, "Login successful", response)
 }
 
@@ -40,6 +64,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		utils.ValidationErrorResponse(c, "Invalid request data")
 		return
 	}
+	req.ClientInfo = clientInfo(c)
 
 	response, err := h.authService.Register(&req)
 	if err != nil {
@@ -56,8 +81,9 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		utils.ValidationErrorResponse(c, "Invalid request data")
 		return
 	}
+	req.ClientInfo = clientInfo(c)
 
-	response, err := h.authService.RefreshToken(req.RefreshToken)
+	response, err := h.authService.RefreshToken(&req)
 	if err != nil {
 		utils.ErrorResponse(c, err)
 		return
@@ -79,10 +105,43 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.Logout(userIDUint); err != nil {
+	accessToken := c.GetString("access_token")
+
+	var req dto.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ValidationErrorResponse(c, "Invalid request data")
+			return
+		}
+	}
+
+	if err := h.authService.Logout(userIDUint, accessToken, req.RefreshToken); err != nil {
 		utils.ErrorResponse(c, err)
 		return
 	}
 
 	utils.SuccessResponse(c, "Logout successful", nil)
 }
+
+// clientInfo describes the client of the request. Its IP address keys the
+// IP lockout, so it comes from forwarding headers only when the router
+// trusts the proxy that sent them.
+func clientInfo(c *gin.Context) dto.ClientInfo {
+	return dto.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+		Locale:    preferredLocale(c.GetHeader("Accept-Language")),
+	}
+}
+
+// preferredLocale returns the first language tag of an Accept-Language
+// header, e.g. "vi-VN" for "vi-VN,vi;q=0.9,en;q=0.8".
+func preferredLocale(acceptLanguage string) string {
+	tag, _, _ := strings.Cut(acceptLanguage, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	tag = strings.TrimSpace(tag)
+	if tag == "*" || len(tag) > 16 {
+		return ""
+	}
+	return tag
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailVerificationHandler struct {
+	emailVerificationService services.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(emailVerificationService services.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		emailVerificationService: emailVerificationService,
+	}
+}
+
+func (h *EmailVerificationHandler) VerifyEmail(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	if err := h.emailVerificationService.VerifyEmail(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Email verified successfully", nil)
+}
+
+func (h *EmailVerificationHandler) ResendVerification(c *gin.Context) {
+	var req dto.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	if err := h.emailVerificationService.ResendVerification(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "If the account exists and is not verified, a verification email has been sent", nil)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"auth-system/internal/infrastructure/security"
+	"auth-system/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	jwtManager *security.JWTManager
+}
+
+func NewJWKSHandler(jwtManager *security.JWTManager) *JWKSHandler {
+	return &JWKSHandler{
+		jwtManager: jwtManager,
+	}
+}
+
+// GetJWKS serves the public signing keys as a bare RFC 7517 key set, without
+// the usual API envelope, so standard JWT libraries can consume it directly.
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	jwks, err := h.jwtManager.JWKS()
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
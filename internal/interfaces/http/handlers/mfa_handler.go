@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaService services.MFAService
+}
+
+func NewMFAHandler(mfaService services.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+	}
+}
+
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req dto.MFAEnrollRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ValidationErrorResponse(c, "Invalid request data")
+			return
+		}
+	}
+
+	enrollment, err := h.mfaService.BeginEnrollment(userIDUint, &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Scan the QR code and confirm with a code from your authenticator", enrollment)
+}
+
+func (h *MFAHandler) ConfirmEnrollment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmEnrollment(userIDUint, &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "MFA enabled successfully, store the recovery codes safely", codes)
+}
+
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	if err := h.mfaService.Disable(userIDUint, &req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "MFA disabled successfully", nil)
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(userIDUint, &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Recovery codes regenerated successfully", codes)
+}
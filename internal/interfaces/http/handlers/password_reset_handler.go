@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordResetHandler struct {
+	passwordResetService services.PasswordResetService
+}
+
+func NewPasswordResetHandler(passwordResetService services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		passwordResetService: passwordResetService,
+	}
+}
+
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	if err := h.passwordResetService.ForgotPassword(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "If the account exists, a password reset email has been sent", nil)
+}
+
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	if err := h.passwordResetService.ResetPassword(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Password reset successfully", nil)
+}
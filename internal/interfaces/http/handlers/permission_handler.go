@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PermissionHandler struct {
+	permissionService services.PermissionService
+}
+
+func NewPermissionHandler(permissionService services.PermissionService) *PermissionHandler {
+	return &PermissionHandler{
+		permissionService: permissionService,
+	}
+}
+
+func (h *PermissionHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.permissionService.ListPermissions()
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permissions retrieved successfully", permissions)
+}
+
+func (h *PermissionHandler) GetPermission(c *gin.Context) {
+	permissionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid permission ID")
+		return
+	}
+
+	permission, err := h.permissionService.GetPermission(uint(permissionID))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permission retrieved successfully", permission)
+}
+
+func (h *PermissionHandler) CreatePermission(c *gin.Context) {
+	var req dto.PermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	permission, err := h.permissionService.CreatePermission(&req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Permission created successfully", permission)
+}
+
+func (h *PermissionHandler) UpdatePermission(c *gin.Context) {
+	permissionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid permission ID")
+		return
+	}
+
+	var req dto.PermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	permission, err := h.permissionService.UpdatePermission(uint(permissionID), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permission updated successfully", permission)
+}
+
+func (h *PermissionHandler) DeletePermission(c *gin.Context) {
+	permissionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid permission ID")
+		return
+	}
+
+	force, _ := strconv.ParseBool(c.Query("force"))
+	if err := h.permissionService.DeletePermission(uint(permissionID), force); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permission deleted successfully", nil)
+}
+
+func (h *PermissionHandler) RegisterPermissions(c *gin.Context) {
+	var req dto.RegisterPermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	permissions, err := h.permissionService.RegisterPermissions(&req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permissions registered successfully", permissions)
+}
+
+// CheckUserPermission explains how a permission check for the user would
+// be decided, to debug grants and denials.
+func (h *PermissionHandler) CheckUserPermission(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	resource, action := c.Query("resource"), c.Query("action")
+	if resource == "" || action == "" {
+		utils.ValidationErrorResponse(c, "resource and action are required")
+		return
+	}
+
+	decision, err := h.permissionService.Decide(uint(userID), resource, action)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permission checked successfully", decision)
+}
+
+func (h *PermissionHandler) ListUserDenials(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	permissions, err := h.permissionService.ListUserDenials(uint(userID))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Denied permissions retrieved successfully", permissions)
+}
+
+func (h *PermissionHandler) DenyUserPermissions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req dto.DeniedPermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	permissions, err := h.permissionService.DenyUserPermissions(uint(userID), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permissions denied successfully", permissions)
+}
+
+func (h *PermissionHandler) RemoveUserDenial(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	permissionID, err := strconv.ParseUint(c.Param("permissionId"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid permission ID")
+		return
+	}
+
+	permissions, err := h.permissionService.RemoveUserDenial(uint(userID), uint(permissionID))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Denial removed successfully", permissions)
+}
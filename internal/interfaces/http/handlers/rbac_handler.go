@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RBACHandler struct {
+	rbacService services.RBACService
+}
+
+func NewRBACHandler(rbacService services.RBACService) *RBACHandler {
+	return &RBACHandler{
+		rbacService: rbacService,
+	}
+}
+
+// ExportManifest serves the catalog as a bare manifest, without the usual
+// API envelope, so it can be saved and applied again. ?format=yaml selects
+// YAML instead of JSON.
+func (h *RBACHandler) ExportManifest(c *gin.Context) {
+	manifest, err := h.rbacService.ExportManifest()
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	if c.Query("format") == "yaml" {
+		c.YAML(http.StatusOK, manifest)
+		return
+	}
+	c.JSON(http.StatusOK, manifest)
+}
+
+// PlanManifest and ApplyManifest accept a JSON or, with a YAML content
+// type, a YAML manifest.
+func (h *RBACHandler) PlanManifest(c *gin.Context) {
+	var manifest dto.RBACManifest
+	if err := c.ShouldBind(&manifest); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	plan, err := h.rbacService.PlanManifest(&manifest)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "RBAC plan computed successfully", plan)
+}
+
+func (h *RBACHandler) ApplyManifest(c *gin.Context) {
+	var manifest dto.RBACManifest
+	if err := c.ShouldBind(&manifest); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	plan, err := h.rbacService.ApplyManifest(&manifest)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "RBAC manifest applied successfully", plan)
+}
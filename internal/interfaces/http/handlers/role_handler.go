@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	roleService services.RoleService
+}
+
+func NewRoleHandler(roleService services.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Roles retrieved successfully", roles)
+}
+
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid role ID")
+		return
+	}
+
+	role, err := h.roleService.GetRole(uint(roleID))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Role retrieved successfully", role)
+}
+
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req dto.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	role, err := h.roleService.CreateRole(&req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Role created successfully", role)
+}
+
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid role ID")
+		return
+	}
+
+	var req dto.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(uint(roleID), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Role updated successfully", role)
+}
+
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid role ID")
+		return
+	}
+
+	force, _ := strconv.ParseBool(c.Query("force"))
+	if err := h.roleService.DeleteRole(uint(roleID), force); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Role deleted successfully", nil)
+}
+
+func (h *RoleHandler) AddPermissions(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid role ID")
+		return
+	}
+
+	var req dto.RolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	role, err := h.roleService.AddPermissions(uint(roleID), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permissions added successfully", role)
+}
+
+func (h *RoleHandler) RemovePermission(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid role ID")
+		return
+	}
+
+	permissionID, err := strconv.ParseUint(c.Param("permissionId"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid permission ID")
+		return
+	}
+
+	role, err := h.roleService.RemovePermission(uint(roleID), uint(permissionID))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permission removed successfully", role)
+}
+
+func (h *RoleHandler) SetParents(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid role ID")
+		return
+	}
+
+	var req dto.RoleParentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	role, err := h.roleService.SetParents(uint(roleID), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Role parents updated successfully", role)
+}
+
+func (h *RoleHandler) AddDeniedPermissions(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid role ID")
+		return
+	}
+
+	var req dto.DeniedPermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	role, err := h.roleService.AddDeniedPermissions(uint(roleID), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Permissions denied successfully", role)
+}
+
+func (h *RoleHandler) RemoveDeniedPermission(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid role ID")
+		return
+	}
+
+	permissionID, err := strconv.ParseUint(c.Param("permissionId"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid permission ID")
+		return
+	}
+
+	role, err := h.roleService.RemoveDeniedPermission(uint(roleID), uint(permissionID))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Denial removed successfully", role)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionService services.SessionService
+}
+
+func NewSessionHandler(sessionService services.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(userIDUint, c.GetUint("session_id"))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Sessions retrieved successfully", sessions)
+}
+
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid session ID")
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(userIDUint, uint(sessionID)); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Session revoked successfully", nil)
+}
+
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.sessionService.RevokeOtherSessions(userIDUint, c.GetUint("session_id")); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Other sessions revoked successfully", nil)
+}
+
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(uint(userID), 0)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Sessions retrieved successfully", sessions)
+}
+
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid session ID")
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(uint(userID), uint(sessionID)); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Session revoked successfully", nil)
+}
+
+func (h *SessionHandler) RevokeAllUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.sessionService.RevokeAllSessions(uint(userID)); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "All sessions revoked successfully", nil)
+}
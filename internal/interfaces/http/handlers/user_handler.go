@@ -19,6 +19,33 @@ func NewUserHandler(userService services.UserService) *UserHandler {
 	}
 }
 
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	var req dto.ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid query parameters")
+		return
+	}
+
+	if req.Pagination == "cursor" || req.After != "" || req.Before != "" {
+		users, err := h.userService.ListUsersByCursor(&req)
+		if err != nil {
+			utils.ErrorResponse(c, err)
+			return
+		}
+
+		utils.SuccessResponse(c, "Users retrieved successfully", users)
+		return
+	}
+
+	users, err := h.userService.ListUsers(&req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Users retrieved successfully", users)
+}
+
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -140,3 +167,122 @@ func (h *UserHandler) RemoveRole(c *gin.Context) {
 
 	utils.SuccessResponse(c, "Role removed successfully", nil)
 }
+
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	userIDParam := c.Param("id")
+	userID, err := strconv.ParseUint(userIDParam, 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.userService.UnlockUser(uint(userID)); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "User unlocked successfully", nil)
+}
+
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req dto.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	user, err := h.userService.CreateUser(c.GetUint("user_id"), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, "User created successfully", user)
+}
+
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req dto.UserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	if err := h.userService.DeactivateUser(c.GetUint("user_id"), uint(userID), &req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "User deactivated successfully", nil)
+}
+
+func (h *UserHandler) ReactivateUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req dto.UserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	if err := h.userService.ReactivateUser(c.GetUint("user_id"), uint(userID), &req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "User reactivated successfully", nil)
+}
+
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.userService.DeleteUser(c.GetUint("user_id"), uint(userID)); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "User deleted successfully", nil)
+}
+
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.userService.RestoreUser(c.GetUint("user_id"), uint(userID)); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "User restored successfully", nil)
+}
+
+func (h *UserHandler) PurgeUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.userService.PurgeUser(c.GetUint("user_id"), uint(userID)); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "User purged successfully", nil)
+}
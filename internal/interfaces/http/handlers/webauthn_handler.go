@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/domain/services"
+	"auth-system/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebAuthnHandler struct {
+	webAuthnService services.WebAuthnService
+	authService     services.AuthService
+}
+
+func NewWebAuthnHandler(webAuthnService services.WebAuthnService, authService services.AuthService) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		webAuthnService: webAuthnService,
+		authService:     authService,
+	}
+}
+
+func (h *WebAuthnHandler) BeginRegistration(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	options, err := h.webAuthnService.BeginRegistration(userIDUint)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WebAuthn registration started", options)
+}
+
+func (h *WebAuthnHandler) FinishRegistration(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	var req dto.WebAuthnRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	credential, err := h.webAuthnService.FinishRegistration(userIDUint, &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.CreatedResponse(c, "Passkey registered successfully", credential)
+}
+
+func (h *WebAuthnHandler) BeginLogin(c *gin.Context) {
+	options, err := h.authService.BeginPasskeyLogin()
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WebAuthn login started", options)
+}
+
+func (h *WebAuthnHandler) FinishLogin(c *gin.Context) {
+	var req dto.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+	req.ClientInfo = clientInfo(c)
+
+	response, err := h.authService.FinishPasskeyLogin(&req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Login successful", response)
+}
+
+func (h *WebAuthnHandler) BeginMFA(c *gin.Context) {
+	var req dto.MFAWebAuthnBeginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, "Invalid request data")
+		return
+	}
+
+	options, err := h.authService.BeginMFAWebAuthn(&req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WebAuthn verification started", options)
+}
+
+func (h *WebAuthnHandler) ListCredentials(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	credentials, err := h.webAuthnService.ListCredentials(userIDUint)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Passkeys retrieved successfully", credentials)
+}
+
+func (h *WebAuthnHandler) DeleteCredential(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ValidationErrorResponse(c, "User not found in context")
+		return
+	}
+
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		utils.ValidationErrorResponse(c, "Invalid user ID")
+		return
+	}
+
+	credentialID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ValidationErrorResponse(c, "Invalid credential ID")
+		return
+	}
+
+	if err := h.webAuthnService.DeleteCredential(userIDUint, uint(credentialID)); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, "Passkey removed successfully", nil)
+}
@@ -1,20 +1,30 @@
 package middleware
 
 import (
+	"auth-system/internal/domain/repositories"
 	"auth-system/internal/infrastructure/security"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AuthMiddleware struct {
-	jwtManager *security.JWTManager
+	jwtManager       *security.JWTManager
+	revokedTokenRepo repositories.RevokedTokenRepository
+	sessionRepo      repositories.SessionRepository
 }
 
-func NewAuthMiddleware(jwtManager *security.JWTManager) *AuthMiddleware {
+func NewAuthMiddleware(
+	jwtManager *security.JWTManager,
+	revokedTokenRepo repositories.RevokedTokenRepository,
+	sessionRepo repositories.SessionRepository,
+) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtManager: jwtManager,
+		jwtManager:       jwtManager,
+		revokedTokenRepo: revokedTokenRepo,
+		sessionRepo:      sessionRepo,
 	}
 }
 
@@ -61,9 +71,30 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		revoked, err := m.isRevoked(claims)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to verify token",
+			})
+			ctx.Abort()
+			return
+		}
+
+		if revoked {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "Token has been revoked",
+			})
+			ctx.Abort()
+			return
+		}
+
 		// Store user information in context
 		ctx.Set("user_id", claims.UserID)
 		ctx.Set("user_email", claims.Email)
+		ctx.Set("access_token", tokenString)
+		ctx.Set("session_id", claims.SessionID)
 		ctx.Next()
 	}
 }
@@ -90,10 +121,40 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 		}
 
 		if claims.Type == "access" {
+			if revoked, err := m.isRevoked(claims); err != nil || revoked {
+				ctx.Next()
+				return
+			}
+
 			ctx.Set("user_id", claims.UserID)
 			ctx.Set("user_email", claims.Email)
+			ctx.Set("access_token", tokenString)
+			ctx.Set("session_id", claims.SessionID)
 		}
 
 		ctx.Next()
 	}
 }
+
+// isRevoked treats tokens without an ID or session as revoked, since they
+// cannot be tracked by the revocation store.
+func (m *AuthMiddleware) isRevoked(claims *security.Claims) (bool, error) {
+	if claims.ID == "" || claims.SessionID == 0 {
+		return true, nil
+	}
+
+	revoked, err := m.revokedTokenRepo.IsRevoked(claims.ID)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	session, err := m.sessionRepo.GetByID(claims.SessionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return session.UserID != claims.UserID || !session.IsActive(), nil
+}
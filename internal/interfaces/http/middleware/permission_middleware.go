@@ -1,14 +1,21 @@
 package middleware
 
 import (
+	"auth-system/internal/application/dto"
 	"auth-system/internal/domain/services"
 	"auth-system/pkg/errors"
 	"auth-system/pkg/utils"
 	"fmt"
+	"log"
 
 	"github.com/gin-gonic/gin"
 )
 
+// permissionDecisionKey is the context key of the decisions made by the
+// middleware, for handlers and logging that need to know why a request was
+// allowed or refused.
+const permissionDecisionKey = "permission_decisions"
+
 type PermissionMiddleware struct {
 	permissionService services.PermissionService
 }
@@ -35,14 +42,16 @@ func (m *PermissionMiddleware) RequirePermission(resource, action string) gin.Ha
 			return
 		}
 
-		hasPermission, err := m.permissionService.CheckPermission(userIDUint, resource, action)
+		decision, err := m.permissionService.Decide(userIDUint, resource, action)
 		if err != nil {
 			utils.ErrorResponse(ctx, errors.NewInternalServerError(fmt.Sprintf("failed to check permission: %v", err)))
 			ctx.Abort()
 			return
 		}
+		recordDecision(ctx, decision)
 
-		if !hasPermission {
+		if !decision.Allowed {
+			logRefusal(userIDUint, decision)
 			utils.ErrorResponse(ctx, errors.NewForbiddenError("Insufficient permissions"))
 			ctx.Abort()
 			return
@@ -68,21 +77,54 @@ func (m *PermissionMiddleware) RequireAnyPermission(permissions []struct{ Resour
 			return
 		}
 
+		// Each alternative is decided on its own: a denial refuses only the
+		// permission it covers, and another alternative may still allow.
 		for _, perm := range permissions {
-			hasPermission, err := m.permissionService.CheckPermission(userIDUint, perm.Resource, perm.Action)
+			decision, err := m.permissionService.Decide(userIDUint, perm.Resource, perm.Action)
 			if err != nil {
 				utils.ErrorResponse(ctx, errors.NewInternalServerError(fmt.Sprintf("Failed to check permission: %v", err)))
 				ctx.Abort()
 				return
 			}
+			recordDecision(ctx, decision)
 
-			if hasPermission {
+			if decision.Allowed {
 				ctx.Next()
 				return
 			}
 		}
 
+		for _, decision := range PermissionDecisions(ctx) {
+			if !decision.Allowed {
+				logRefusal(userIDUint, decision)
+			}
+		}
 		utils.ErrorResponse(ctx, errors.NewForbiddenError("Insufficient permissions"))
 		ctx.Abort()
 	}
 }
+
+// recordDecision appends the decision to those of the request.
+func recordDecision(ctx *gin.Context, decision *dto.PermissionDecision) {
+	decisions := PermissionDecisions(ctx)
+	ctx.Set(permissionDecisionKey, append(decisions, decision))
+}
+
+// PermissionDecisions returns the permission checks made for the request
+// so far, in order, each with the reason for its outcome.
+func PermissionDecisions(ctx *gin.Context) []*dto.PermissionDecision {
+	if value, ok := ctx.Get(permissionDecisionKey); ok {
+		if decisions, ok := value.([]*dto.PermissionDecision); ok {
+			return decisions
+		}
+	}
+	return nil
+}
+
+// logRefusal logs why a check failed when gin runs in debug mode; the
+// response itself does not tell the client.
+func logRefusal(userID uint, decision *dto.PermissionDecision) {
+	if gin.IsDebugging() {
+		log.Printf("Permission %s.%s refused to user %d: %s", decision.Resource, decision.Action, userID, decision.Reason)
+	}
+}
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"auth-system/internal/domain/repositories"
+	"auth-system/pkg/errors"
+	"auth-system/pkg/utils"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit is a token bucket: Burst requests at once, refilled at Rate
+// requests per second. The zero value disables the limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseRateLimit parses "<requests>/<period>", e.g. "5/1m" allows bursts of
+// 5 requests and 5 more every minute. An empty string or "0" disables it.
+func ParseRateLimit(spec string) (RateLimit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "0" {
+		return RateLimit{}, nil
+	}
+
+	count, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q, expected <requests>/<period>", spec)
+	}
+
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid request count in rate limit %q", spec)
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid period in rate limit %q", spec)
+	}
+
+	return RateLimit{
+		Rate:  float64(requests) / duration.Seconds(),
+		Burst: requests,
+	}, nil
+}
+
+// RefillTime is how long an empty bucket takes to fill up again.
+func (l RateLimit) RefillTime() time.Duration {
+	if l.Burst == 0 {
+		return 0
+	}
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// RateLimits holds the per-route limits applied by the router.
+type RateLimits struct {
+	Login        RateLimit // per IP, shared by password and passkey login
+	LoginEmail   RateLimit // per email
+	Register     RateLimit // per IP
+	Refresh      RateLimit // per IP
+	MFA          RateLimit // per IP
+	Email        RateLimit // per IP, verification and password reset
+	EmailAddress RateLimit // per email, verification and password reset mails
+	User         RateLimit // per authenticated user
+}
+
+// RefillTime is the longest refill time of all limits. Buckets untouched
+// for that long are full and can be deleted.
+func (l RateLimits) RefillTime() time.Duration {
+	return max(
+		l.Login.RefillTime(),
+		l.LoginEmail.RefillTime(),
+		l.Register.RefillTime(),
+		l.Refresh.RefillTime(),
+		l.MFA.RefillTime(),
+		l.Email.RefillTime(),
+		l.EmailAddress.RefillTime(),
+		l.User.RefillTime(),
+	)
+}
+
+type RateLimitMiddleware struct {
+	store repositories.RateLimitRepository
+}
+
+func NewRateLimitMiddleware(store repositories.RateLimitRepository) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		store: store,
+	}
+}
+
+// ByIP limits requests per client IP. Routes sharing a name share a bucket.
+func (m *RateLimitMiddleware) ByIP(name string, limit RateLimit) gin.HandlerFunc {
+	return m.limit(name+":ip", limit, func(ctx *gin.Context) string {
+		return ctx.ClientIP()
+	})
+}
+
+// ByEmail limits requests per "email" field of the JSON body, so one
+// account cannot be targeted from many IPs. The body is left readable for
+// the handler.
+func (m *RateLimitMiddleware) ByEmail(name string, limit RateLimit) gin.HandlerFunc {
+	return m.limit(name+":email", limit, func(ctx *gin.Context) string {
+		if ctx.Request.Body == nil {
+			return ""
+		}
+
+		body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, 1<<20))
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return ""
+		}
+
+		var payload struct {
+			Email string `json:"email"`
+		}
+		if json.Unmarshal(body, &payload) != nil {
+			return ""
+		}
+		return strings.ToLower(strings.TrimSpace(payload.Email))
+	})
+}
+
+// ByUser limits requests per authenticated user and must run after
+// RequireAuth.
+func (m *RateLimitMiddleware) ByUser(name string, limit RateLimit) gin.HandlerFunc {
+	return m.limit(name+":user", limit, func(ctx *gin.Context) string {
+		if userID := ctx.GetUint("user_id"); userID != 0 {
+			return strconv.FormatUint(uint64(userID), 10)
+		}
+		return ""
+	})
+}
+
+func (m *RateLimitMiddleware) limit(prefix string, limit RateLimit, keyFunc func(ctx *gin.Context) string) gin.HandlerFunc {
+	if limit.Burst == 0 {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+
+	return func(ctx *gin.Context) {
+		key := keyFunc(ctx)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		allowed, retryAfter, err := m.store.Take(bucketKey(prefix, key), limit.Rate, limit.Burst, time.Now())
+		if err != nil {
+			// Fail open: an unavailable store must not take login down.
+			log.Printf("Rate limit check failed: %v", err)
+			ctx.Next()
+			return
+		}
+
+		if !allowed {
+			utils.ErrorResponse(ctx, errors.NewTooManyRequestsError("Too many requests, please try again later", retryAfter))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// bucketKey names the bucket of key. Keys come from the client, so they are
+// hashed to a fixed length: an oversized one would make the store fail, and
+// the limit fail open.
+func bucketKey(prefix, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return prefix + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"auth-system/internal/domain/repositories"
+	infrarepos "auth-system/internal/infrastructure/repositories"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		spec       string
+		want       RateLimit
+		wantRefill time.Duration
+	}{
+		{"", RateLimit{}, 0},
+		{"0", RateLimit{}, 0},
+		{"  ", RateLimit{}, 0},
+		{"5/1m", RateLimit{Rate: 5.0 / 60, Burst: 5}, time.Minute},
+		{"10/1s", RateLimit{Rate: 10, Burst: 10}, time.Second},
+		{" 3/15m ", RateLimit{Rate: 3.0 / 900, Burst: 3}, 15 * time.Minute},
+		{"1/500ms", RateLimit{Rate: 2, Burst: 1}, 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseRateLimit(%q) error = %v", tt.spec, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRateLimit(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+			if refill := got.RefillTime(); refill.Round(time.Millisecond) != tt.wantRefill {
+				t.Errorf("RefillTime = %s, want %s", refill, tt.wantRefill)
+			}
+		})
+	}
+}
+
+func TestParseRateLimitErrors(t *testing.T) {
+	for _, spec := range []string{
+		"5",
+		"5/",
+		"/1m",
+		"five/1m",
+		"0/1m",
+		"-1/1m",
+		"5/0s",
+		"5/-1m",
+		"5/minute",
+		"5/1m/2",
+		"1.5/1m",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			if got, err := ParseRateLimit(spec); err == nil {
+				t.Errorf("ParseRateLimit(%q) = %+v, want an error", spec, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limit, err := ParseRateLimit("2/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.GET("/", NewRateLimitMiddleware(infrarepos.NewMemoryRateLimitRepository()).ByIP("test", limit), func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for i := 0; i < 2; i++ {
+		if got := request("192.0.2.1").Code; got != http.StatusNoContent {
+			t.Fatalf("request %d status = %d, want %d", i+1, got, http.StatusNoContent)
+		}
+	}
+
+	limited := request("192.0.2.1")
+	if limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", limited.Code, http.StatusTooManyRequests)
+	}
+	// One token comes back every 30 seconds.
+	if got := limited.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	if !strings.Contains(limited.Body.String(), `"retry_after":30`) {
+		t.Errorf("body = %s, want retry_after 30", limited.Body.String())
+	}
+
+	// Other clients have their own bucket.
+	if got := request("192.0.2.2").Code; got != http.StatusNoContent {
+		t.Errorf("other IP status = %d, want %d", got, http.StatusNoContent)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/", NewRateLimitMiddleware(infrarepos.NewMemoryRateLimitRepository()).ByIP("test", RateLimit{}), func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	for i := 0; i < 100; i++ {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		if recorder.Code != http.StatusNoContent {
+			t.Fatalf("request %d status = %d, want %d", i+1, recorder.Code, http.StatusNoContent)
+		}
+	}
+}
+
+// boundedRateLimitStore refuses keys longer than the key column of the
+// Postgres store.
+type boundedRateLimitStore struct {
+	repositories.RateLimitRepository
+}
+
+func (s *boundedRateLimitStore) Take(key string, rate float64, burst int, now time.Time) (bool, time.Duration, error) {
+	if len(key) > 255 {
+		return false, 0, fmt.Errorf("key of %d bytes is too long", len(key))
+	}
+	return s.RateLimitRepository.Take(key, rate, burst, now)
+}
+
+func TestRateLimitLongKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limit, err := ParseRateLimit("1/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &boundedRateLimitStore{infrarepos.NewMemoryRateLimitRepository()}
+	router := gin.New()
+	router.POST("/", NewRateLimitMiddleware(store).ByEmail("test", limit), func(ctx *gin.Context) {
+		ctx.Status(http.StatusNoContent)
+	})
+
+	request := func(email string) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"`+email+`"}`)))
+		return recorder.Code
+	}
+
+	long := strings.Repeat("a", 1000) + "@example.com"
+	if got := request(long); got != http.StatusNoContent {
+		t.Fatalf("first request status = %d, want %d", got, http.StatusNoContent)
+	}
+	if got := request(long); got != http.StatusTooManyRequests {
+		t.Errorf("second request with a long email status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+	if got := request(strings.Repeat("a", 999) + "@example.com"); got != http.StatusNoContent {
+		t.Errorf("other email status = %d, want %d", got, http.StatusNoContent)
+	}
+}
@@ -3,34 +3,78 @@ package routes
 import (
 	"auth-system/internal/interfaces/http/handlers"
 	"auth-system/internal/interfaces/http/middleware"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Router struct {
-	authHandler    *handlers.AuthHandler
-	userHandler    *handlers.UserHandler
-	authMiddleware *middleware.AuthMiddleware
-	permMiddleware *middleware.PermissionMiddleware
+	authHandler     *handlers.AuthHandler
+	userHandler     *handlers.UserHandler
+	sessionHandler  *handlers.SessionHandler
+	jwksHandler     *handlers.JWKSHandler
+	mfaHandler      *handlers.MFAHandler
+	webAuthnHandler *handlers.WebAuthnHandler
+	verifyHandler   *handlers.EmailVerificationHandler
+	resetHandler    *handlers.PasswordResetHandler
+	roleHandler     *handlers.RoleHandler
+	permHandler     *handlers.PermissionHandler
+	rbacHandler     *handlers.RBACHandler
+	authMiddleware  *middleware.AuthMiddleware
+	permMiddleware  *middleware.PermissionMiddleware
+	rateLimit       *middleware.RateLimitMiddleware
+	rateLimits      middleware.RateLimits
+	trustedProxies  []string
 }
 
 func NewRouter(
 	authHandler *handlers.AuthHandler,
 	userHandler *handlers.UserHandler,
+	sessionHandler *handlers.SessionHandler,
+	jwksHandler *handlers.JWKSHandler,
+	mfaHandler *handlers.MFAHandler,
+	webAuthnHandler *handlers.WebAuthnHandler,
+	verifyHandler *handlers.EmailVerificationHandler,
+	resetHandler *handlers.PasswordResetHandler,
+	roleHandler *handlers.RoleHandler,
+	permHandler *handlers.PermissionHandler,
+	rbacHandler *handlers.RBACHandler,
 	authMiddleware *middleware.AuthMiddleware,
 	permMiddleware *middleware.PermissionMiddleware,
+	rateLimit *middleware.RateLimitMiddleware,
+	rateLimits middleware.RateLimits,
+	trustedProxies []string,
 ) *Router {
 	return &Router{
-		authHandler:    authHandler,
-		userHandler:    userHandler,
-		authMiddleware: authMiddleware,
-		permMiddleware: permMiddleware,
+		authHandler:     authHandler,
+		userHandler:     userHandler,
+		sessionHandler:  sessionHandler,
+		jwksHandler:     jwksHandler,
+		mfaHandler:      mfaHandler,
+		webAuthnHandler: webAuthnHandler,
+		verifyHandler:   verifyHandler,
+		resetHandler:    resetHandler,
+		roleHandler:     roleHandler,
+		permHandler:     permHandler,
+		rbacHandler:     rbacHandler,
+		authMiddleware:  authMiddleware,
+		permMiddleware:  permMiddleware,
+		rateLimit:       rateLimit,
+		rateLimits:      rateLimits,
+		trustedProxies:  trustedProxies,
 	}
 }
 
-func (r *Router) SetupRoutes() *gin.Engine {
+func (r *Router) SetupRoutes() (*gin.Engine, error) {
 	router := gin.Default()
 
+	// Client IPs come from forwarding headers only when the request
+	// arrives through a trusted proxy; otherwise they could be spoofed to
+	// dodge rate limits and lockouts.
+	if err := router.SetTrustedProxies(r.trustedProxies); err != nil {
+		return nil, fmt.Errorf("Invalid trusted proxies: %w", err)
+	}
+
 	// CORS middleware
 	router.Use(middleware.CORS())
 
@@ -42,34 +86,123 @@ func (r *Router) SetupRoutes() *gin.Engine {
 		})
 	})
 
+	// Public signing keys for services that verify our tokens
+	router.GET("/.well-known/jwks.json", r.jwksHandler.GetJWKS)
+
 	api := router.Group("/api/v1")
 
+	// Rate limits; routes using the same name share a bucket
+	loginLimit := r.rateLimit.ByIP("login", r.rateLimits.Login)
+	loginEmailLimit := r.rateLimit.ByEmail("login", r.rateLimits.LoginEmail)
+	mfaLimit := r.rateLimit.ByIP("mfa", r.rateLimits.MFA)
+	emailLimit := r.rateLimit.ByIP("email", r.rateLimits.Email)
+	emailAddressLimit := r.rateLimit.ByEmail("email", r.rateLimits.EmailAddress)
+	userLimit := r.rateLimit.ByUser("api", r.rateLimits.User)
+
 	// Public routes
 	auth := api.Group("/auth")
 	{
-		auth.POST("/login", r.authHandler.Login)
-		auth.POST("/register", r.authHandler.Register)
-		auth.POST("/refresh", r.authHandler.RefreshToken)
+		auth.POST("/login", loginLimit, loginEmailLimit, r.authHandler.Login)
+		auth.POST("/register", r.rateLimit.ByIP("register", r.rateLimits.Register), r.authHandler.Register)
+		auth.POST("/mfa/verify", mfaLimit, r.authHandler.VerifyMFA)
+		auth.POST("/verify-email", emailLimit, r.verifyHandler.VerifyEmail)
+		auth.POST("/resend-verification", emailLimit, emailAddressLimit, r.verifyHandler.ResendVerification)
+		auth.POST("/forgot-password", emailLimit, emailAddressLimit, r.resetHandler.ForgotPassword)
+		auth.POST("/reset-password", emailLimit, r.resetHandler.ResetPassword)
+		auth.POST("/refresh", r.rateLimit.ByIP("refresh", r.rateLimits.Refresh), r.authHandler.RefreshToken)
 		auth.POST("/logout", r.authMiddleware.RequireAuth(), r.authHandler.Logout)
 	}
 
+	webauthn := auth.Group("/webauthn")
+	{
+		webauthn.POST("/register/begin", r.authMiddleware.RequireAuth(), userLimit, r.webAuthnHandler.BeginRegistration)
+		webauthn.POST("/register/finish", r.authMiddleware.RequireAuth(), userLimit, r.webAuthnHandler.FinishRegistration)
+		webauthn.POST("/login/begin", loginLimit, r.webAuthnHandler.BeginLogin)
+		webauthn.POST("/login/finish", loginLimit, r.webAuthnHandler.FinishLogin)
+		webauthn.POST("/mfa/begin", mfaLimit, r.webAuthnHandler.BeginMFA)
+	}
+
 	// Protected routes
 	user := api.Group("/user")
 	user.Use(r.authMiddleware.RequireAuth())
+	user.Use(userLimit)
 	{
 		user.GET("/profile", r.userHandler.GetProfile)
 		user.PUT("/profile", r.userHandler.UpdateProfile)
 		user.POST("/change-password", r.userHandler.ChangePassword)
+		user.GET("/sessions", r.sessionHandler.ListSessions)
+		user.DELETE("/sessions/:id", r.sessionHandler.RevokeSession)
+		user.POST("/sessions/revoke-others", r.sessionHandler.RevokeOtherSessions)
+		user.POST("/mfa/enroll", r.mfaHandler.Enroll)
+		user.POST("/mfa/confirm", r.mfaHandler.ConfirmEnrollment)
+		user.POST("/mfa/disable", r.mfaHandler.Disable)
+		user.POST("/mfa/recovery-codes", r.mfaHandler.RegenerateRecoveryCodes)
+		user.GET("/webauthn/credentials", r.webAuthnHandler.ListCredentials)
+		user.DELETE("/webauthn/credentials/:id", r.webAuthnHandler.DeleteCredential)
 	}
 
 	// Admin routes
 	admin := api.Group("/admin")
 	admin.Use(r.authMiddleware.RequireAuth())
-	admin.Use(r.permMiddleware.RequirePermission("users", "write"))
+	admin.Use(userLimit)
+
+	readUsers := r.permMiddleware.RequirePermission("users", "read")
+	writeUsers := r.permMiddleware.RequirePermission("users", "write")
+	deleteUsers := r.permMiddleware.RequirePermission("users", "delete")
+
+	users := admin.Group("/users")
+	{
+		users.GET("", readUsers, r.userHandler.ListUsers)
+		users.POST("", writeUsers, r.userHandler.CreateUser)
+		users.DELETE("/:id", deleteUsers, r.userHandler.DeleteUser)
+		users.POST("/:id/restore", deleteUsers, r.userHandler.RestoreUser)
+		users.DELETE("/:id/purge", deleteUsers, r.userHandler.PurgeUser)
+		users.POST("/:id/deactivate", writeUsers, r.userHandler.DeactivateUser)
+		users.POST("/:id/reactivate", writeUsers, r.userHandler.ReactivateUser)
+		users.POST("/:id/roles", writeUsers, r.userHandler.AssignRole)
+		users.DELETE("/:id/roles/:roleId", writeUsers, r.userHandler.RemoveRole)
+		users.POST("/:id/unlock", writeUsers, r.userHandler.UnlockUser)
+		users.GET("/:id/permissions/check", r.permMiddleware.RequirePermission("roles", "read"), r.permHandler.CheckUserPermission)
+		users.GET("/:id/denied-permissions", r.permMiddleware.RequirePermission("roles", "read"), r.permHandler.ListUserDenials)
+		users.POST("/:id/denied-permissions", r.permMiddleware.RequirePermission("roles", "write"), r.permHandler.DenyUserPermissions)
+		users.DELETE("/:id/denied-permissions/:permissionId", r.permMiddleware.RequirePermission("roles", "write"), r.permHandler.RemoveUserDenial)
+		users.GET("/:id/sessions", writeUsers, r.sessionHandler.ListUserSessions)
+		users.DELETE("/:id/sessions", writeUsers, r.sessionHandler.RevokeAllUserSessions)
+		users.DELETE("/:id/sessions/:sessionId", writeUsers, r.sessionHandler.RevokeUserSession)
+	}
+
+	roles := admin.Group("/roles")
+	{
+		roles.GET("", r.permMiddleware.RequirePermission("roles", "read"), r.roleHandler.ListRoles)
+		roles.GET("/:id", r.permMiddleware.RequirePermission("roles", "read"), r.roleHandler.GetRole)
+		roles.POST("", r.permMiddleware.RequirePermission("roles", "write"), r.roleHandler.CreateRole)
+		roles.PUT("/:id", r.permMiddleware.RequirePermission("roles", "write"), r.roleHandler.UpdateRole)
+		roles.DELETE("/:id", r.permMiddleware.RequirePermission("roles", "delete"), r.roleHandler.DeleteRole)
+		roles.POST("/:id/permissions", r.permMiddleware.RequirePermission("roles", "write"), r.roleHandler.AddPermissions)
+		roles.DELETE("/:id/permissions/:permissionId", r.permMiddleware.RequirePermission("roles", "write"), r.roleHandler.RemovePermission)
+		roles.POST("/:id/denied-permissions", r.permMiddleware.RequirePermission("roles", "write"), r.roleHandler.AddDeniedPermissions)
+		roles.DELETE("/:id/denied-permissions/:permissionId", r.permMiddleware.RequirePermission("roles", "write"), r.roleHandler.RemoveDeniedPermission)
+		roles.PUT("/:id/parents", r.permMiddleware.RequirePermission("roles", "write"), r.roleHandler.SetParents)
+	}
+
+	// The permission catalog is part of role management
+	permissions := admin.Group("/permissions")
+	{
+		permissions.GET("", r.permMiddleware.RequirePermission("roles", "read"), r.permHandler.ListPermissions)
+		permissions.GET("/:id", r.permMiddleware.RequirePermission("roles", "read"), r.permHandler.GetPermission)
+		permissions.POST("", r.permMiddleware.RequirePermission("roles", "write"), r.permHandler.CreatePermission)
+		permissions.PUT("", r.permMiddleware.RequirePermission("roles", "write"), r.permHandler.RegisterPermissions)
+		permissions.PUT("/:id", r.permMiddleware.RequirePermission("roles", "write"), r.permHandler.UpdatePermission)
+		permissions.DELETE("/:id", r.permMiddleware.RequirePermission("roles", "delete"), r.permHandler.DeletePermission)
+	}
+
+	// Declarative management of the whole catalog
+	rbac := admin.Group("/rbac")
 	{
-		admin.POST("/users/:id/roles", r.userHandler.AssignRole)
-		admin.DELETE("/users/:id/roles/:roleId", r.userHandler.RemoveRole)
+		rbac.GET("", r.permMiddleware.RequirePermission("roles", "read"), r.rbacHandler.ExportManifest)
+		rbac.POST("/plan", r.permMiddleware.RequirePermission("roles", "write"), r.rbacHandler.PlanManifest)
+		rbac.POST("/apply", r.permMiddleware.RequirePermission("roles", "write"), r.rbacHandler.ApplyManifest)
 	}
 
-	return router
+	return router, nil
 }
@@ -0,0 +1,233 @@
+package routes
+
+import (
+	"auth-system/internal/application/dto"
+	"auth-system/internal/application/services"
+	"auth-system/internal/domain/entities"
+	domainrepos "auth-system/internal/domain/repositories"
+	domainservices "auth-system/internal/domain/services"
+	"auth-system/internal/infrastructure/repositories"
+	"auth-system/internal/infrastructure/security"
+	"auth-system/internal/interfaces/http/handlers"
+	"auth-system/internal/interfaces/http/middleware"
+	"auth-system/pkg/errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type fakeSessionRepository struct {
+	domainrepos.SessionRepository
+	sessions map[uint]*entities.Session
+}
+
+func (r *fakeSessionRepository) GetByID(id uint) (*entities.Session, error) {
+	if session, ok := r.sessions[id]; ok {
+		return session, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeRevokedTokenRepository struct {
+	domainrepos.RevokedTokenRepository
+}
+
+func (r *fakeRevokedTokenRepository) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+// fakePermissionRepository holds the effective grants of each user.
+type fakePermissionRepository struct {
+	domainrepos.PermissionRepository
+	grants map[uint][]*entities.PermissionGrant
+}
+
+func (r *fakePermissionRepository) GetGrantsByUserID(userID uint) ([]*entities.PermissionGrant, error) {
+	return r.grants[userID], nil
+}
+
+func (r *fakePermissionRepository) Version() (int64, error) {
+	return 0, nil
+}
+
+// fakeAuthService records the login requests it refuses.
+type fakeAuthService struct {
+	domainservices.AuthService
+	logins []*dto.LoginRequest
+}
+
+func (s *fakeAuthService) Login(req *dto.LoginRequest) (*dto.AuthResponse, error) {
+	s.logins = append(s.logins, req)
+	return nil, errors.NewUnauthorizedError("Invalid credentials")
+}
+
+type fakeUserService struct {
+	domainservices.UserService
+}
+
+func (s *fakeUserService) ListUsers(req *dto.ListUsersRequest) (*dto.PaginatedResponse, error) {
+	return &dto.PaginatedResponse{}, nil
+}
+
+// testRouter serves the API for two users: 1 holds only the default user
+// role, 2 is an admin.
+func testRouter(t *testing.T, rateLimits middleware.RateLimits, trustedProxies ...string) (*gin.Engine, *security.JWTManager) {
+	engine, jwtManager, _ := testRouterWithAuth(t, rateLimits, trustedProxies...)
+	return engine, jwtManager
+}
+
+func testRouterWithAuth(t *testing.T, rateLimits middleware.RateLimits, trustedProxies ...string) (*gin.Engine, *security.JWTManager, *fakeAuthService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	jwtManager, err := security.NewJWTManager(security.NewKeyring(security.NewHMACSigningKey("test", []byte("test-secret"))), "15m", "24h")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	sessionRepo := &fakeSessionRepository{sessions: map[uint]*entities.Session{
+		1: {ID: 1, UserID: 1, ExpiresAt: expiresAt},
+		2: {ID: 2, UserID: 2, ExpiresAt: expiresAt},
+	}}
+	permissionRepo := &fakePermissionRepository{grants: map[uint][]*entities.PermissionGrant{
+		2: {{
+			Permission: entities.Permission{Name: "users.read", Resource: "users", Action: "read"},
+			Effect:     entities.PermissionEffectAllow,
+			Role:       "admin",
+		}},
+	}}
+
+	authService := &fakeAuthService{}
+	router := NewRouter(
+		handlers.NewAuthHandler(authService),
+		handlers.NewUserHandler(&fakeUserService{}),
+		nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		middleware.NewAuthMiddleware(jwtManager, &fakeRevokedTokenRepository{}, sessionRepo),
+		middleware.NewPermissionMiddleware(services.NewPermissionService(permissionRepo, nil)),
+		middleware.NewRateLimitMiddleware(repositories.NewMemoryRateLimitRepository()),
+		rateLimits,
+		trustedProxies,
+	)
+	engine, err := router.SetupRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return engine, jwtManager, authService
+}
+
+func TestListUsersRequiresUsersRead(t *testing.T) {
+	router, jwtManager := testRouter(t, middleware.RateLimits{})
+
+	tests := []struct {
+		name   string
+		userID uint
+		want   int
+	}{
+		{"plain user", 1, http.StatusForbidden},
+		{"admin", 2, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := jwtManager.GenerateTokenPair(tt.userID, "user@example.com", tt.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users?deleted=true", nil)
+			req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.want {
+				t.Errorf("GET /api/v1/admin/users status = %d, want %d: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestRateLimitIgnoresUntrustedForwardedFor(t *testing.T) {
+	limits := middleware.RateLimits{Login: middleware.RateLimit{Rate: 1.0 / 60, Burst: 1}}
+
+	// The empty body is refused by the handler, after the rate limit.
+	login := func(router *gin.Engine, remoteAddr, forwardedFor string) int {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	t.Run("untrusted peer", func(t *testing.T) {
+		router, _ := testRouter(t, limits)
+
+		if got := login(router, "192.0.2.1:1234", "198.51.100.1"); got != http.StatusBadRequest {
+			t.Fatalf("first login status = %d, want %d", got, http.StatusBadRequest)
+		}
+		if got := login(router, "192.0.2.1:1234", "198.51.100.2"); got != http.StatusTooManyRequests {
+			t.Errorf("login with another X-Forwarded-For status = %d, want %d", got, http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		router, _ := testRouter(t, limits, "10.0.0.0/8")
+
+		if got := login(router, "10.0.0.1:1234", "198.51.100.1"); got != http.StatusBadRequest {
+			t.Fatalf("first login status = %d, want %d", got, http.StatusBadRequest)
+		}
+		if got := login(router, "10.0.0.1:1234", "198.51.100.2"); got != http.StatusBadRequest {
+			t.Errorf("login of another client status = %d, want %d", got, http.StatusBadRequest)
+		}
+		if got := login(router, "10.0.0.1:1234", "198.51.100.1"); got != http.StatusTooManyRequests {
+			t.Errorf("second login of the first client status = %d, want %d", got, http.StatusTooManyRequests)
+		}
+	})
+}
+
+// The IP lockout is keyed on the address the handler passes to Login.
+func TestLoginClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		want           string
+	}{
+		{"untrusted peer", nil, "192.0.2.1:1234", "192.0.2.1"},
+		{"trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.1:1234", "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _, authService := testRouterWithAuth(t, middleware.RateLimits{}, tt.trustedProxies...)
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"user@example.com","password":"wrong-password"}`))
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Forwarded-For", "198.51.100.1")
+			req.Header.Set("X-Real-IP", "198.51.100.1")
+			router.ServeHTTP(recorder, req)
+
+			if len(authService.logins) != 1 {
+				t.Fatalf("Login called %d times, want 1 (status %d)", len(authService.logins), recorder.Code)
+			}
+			if got := authService.logins[0].IPAddress; got != tt.want {
+				t.Errorf("Login IP address = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetupRoutesRejectsInvalidTrustedProxies(t *testing.T) {
+	router := NewRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, middleware.RateLimits{}, []string{"not-an-ip"})
+	if _, err := router.SetupRoutes(); err == nil {
+		t.Error("SetupRoutes accepted an invalid trusted proxy")
+	}
+}
@@ -1,10 +1,15 @@
 package errors
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type AppError struct {
 	Code    int    `json:"code"`
 	Message string `json:"messsage"`
+	// RetryAfter tells the client when the request may succeed again.
+	RetryAfter time.Duration `json:"-"`
 }
 
 func (e *AppError) Error() string {
@@ -39,9 +44,33 @@ func NewForbiddenError(message string) *AppError {
 	}
 }
 
+func NewConflictError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusConflict,
+		Message: message,
+	}
+}
+
 func NewInternalServerError(message string) *AppError {
 	return &AppError{
 		Code:    http.StatusInternalServerError,
 		Message: message,
 	}
 }
+
+// NewLockedError reports a temporarily locked account.
+func NewLockedError(message string, retryAfter time.Duration) *AppError {
+	return &AppError{
+		Code:       http.StatusLocked,
+		Message:    message,
+		RetryAfter: retryAfter,
+	}
+}
+
+func NewTooManyRequestsError(message string, retryAfter time.Duration) *AppError {
+	return &AppError{
+		Code:       http.StatusTooManyRequests,
+		Message:    message,
+		RetryAfter: retryAfter,
+	}
+}
@@ -3,7 +3,9 @@ package utils
 import (
 	"auth-system/internal/application/dto"
 	"auth-system/pkg/errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,9 +28,17 @@ func CreatedResponse(c *gin.Context, message string, data interface{}) {
 
 func ErrorResponse(c *gin.Context, err error) {
 	if appErr, ok := err.(*errors.AppError); ok {
+		var data interface{}
+		if appErr.RetryAfter > 0 {
+			seconds := int64(math.Ceil(appErr.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+			data = gin.H{"retry_after": seconds}
+		}
+
 		c.JSON(appErr.Code, dto.APIResponse{
 			Success: false,
 			Message: appErr.Message,
+			Data:    data,
 			Error:   appErr.Message,
 		})
 		return
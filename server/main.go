@@ -1,19 +1,29 @@
 package main
 
 import (
-	"auth-system/internal/application/services"
+	"auth-system/internal/app"
 	"auth-system/internal/config"
+	domainrepos "auth-system/internal/domain/repositories"
 	"auth-system/internal/infrastructure/database"
+	"auth-system/internal/infrastructure/jobs"
 	"auth-system/internal/infrastructure/repositories"
-	"auth-system/internal/infrastructure/security"
 	"auth-system/internal/interfaces/http/handlers"
 	"auth-system/internal/interfaces/http/middleware"
 	"auth-system/internal/interfaces/http/routes"
+	"context"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	rotateSigningKey := flag.Bool("rotate-signing-key", false, "Activate a new JWT signing key and exit")
+	flag.Parse()
+
 	cfg := config.Load()
 
 	db, err := database.NewConnection(&cfg.Database)
@@ -21,54 +31,217 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	if err := database.AutoMigrate(db); err != nil {
-		log.Fatal("Failed to run migrations:", err)
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	if cfg.Database.AutoMigrate {
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatal("Failed to run migrations:", err)
+		}
+		for _, migration := range applied {
+			log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+		}
+	} else {
+		pending, err := migrator.Pending()
+		if err != nil {
+			log.Fatal("Failed to check migrations:", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("%d database migrations are pending; run `authctl migrate up` first", len(pending))
+		}
 	}
 
-	jwtManager, err := security.NewJWTManager(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessTokenTTL,
-		cfg.JWT.RefreshTokenTTL,
-	)
+	if err := database.Seed(db, &cfg.Bootstrap); err != nil {
+		log.Fatal("Failed to seed database:", err)
+	}
+
+	application, err := app.New(cfg, db)
 	if err != nil {
-		log.Fatal("Failed to initialize JWT manager:", err)
+		log.Fatal("Failed to initialize application:", err)
 	}
+	defer application.Close()
 
-	passwordManager := security.NewPasswordManager()
+	if cfg.RBAC.ManifestPath != "" {
+		manifest, err := app.LoadRBACManifest(cfg.RBAC.ManifestPath)
+		if err != nil {
+			log.Fatal("Failed to load RBAC manifest:", err)
+		}
+		plan, err := application.RBACService.ApplyManifest(manifest)
+		if err != nil {
+			log.Fatal("Failed to apply RBAC manifest:", err)
+		}
+		if plan.HasChanges {
+			log.Printf("Applied RBAC manifest %s: %d roles created, %d permissions created, %d grants added, %d grants removed",
+				cfg.RBAC.ManifestPath, len(plan.CreateRoles), len(plan.CreatePermissions), len(plan.Grant), len(plan.Revoke))
+		}
+	}
 
-	// Initialize repositories
-	userRepo := repositories.NewUserRepository(db)
-	roleRepo := repositories.NewRoleRepository(db)
-	permissionRepo := repositories.NewPermissionRepository(db)
+	signingKeyService := application.SigningKeyService
+	if *rotateSigningKey {
+		key, err := signingKeyService.RotateSigningKey()
+		if err != nil {
+			log.Fatal("Failed to rotate signing key:", err)
+		}
+		log.Printf("Activated signing key %s (%s)", key.KID, key.Algorithm)
+		return
+	}
 
-	// Initialize services
-	authService := services.NewAuthService(userRepo, roleRepo, jwtManager, passwordManager)
-	userService := services.NewUserService(userRepo, roleRepo, passwordManager)
-	permissionService := services.NewPermissionService(permissionRepo, userRepo)
+	keySyncInterval, err := time.ParseDuration(cfg.JWT.KeySyncInterval)
+	if err != nil {
+		log.Fatal("Invalid signing key sync interval:", err)
+	}
+	application.Keyring.SetRefresher(signingKeyService.SyncKeys, 10*time.Second)
+	stopKeySync := jobs.RunPeriodically("sync-signing-keys", keySyncInterval, func() error {
+		if _, err := signingKeyService.RotateIfDue(); err != nil {
+			return err
+		}
+		if err := signingKeyService.PruneExpiredKeys(); err != nil {
+			return err
+		}
+		return signingKeyService.SyncKeys()
+	})
+	defer stopKeySync()
+
+	rateLimits, err := newRateLimits(&cfg.RateLimit)
+	if err != nil {
+		log.Fatal("Invalid rate limit configuration:", err)
+	}
+
+	var rateLimitRepo domainrepos.RateLimitRepository
+	switch cfg.RateLimit.Store {
+	case "memory":
+		rateLimitRepo = repositories.NewMemoryRateLimitRepository()
+	case "postgres":
+		rateLimitRepo = repositories.NewRateLimitRepository(db)
+	default:
+		log.Fatalf("Unknown rate limit store: %s", cfg.RateLimit.Store)
+	}
+
+	pruneInterval, err := time.ParseDuration(cfg.JWT.RevocationPruneInterval)
+	if err != nil {
+		log.Fatal("Invalid token revocation prune interval:", err)
+	}
+	stopPruner := jobs.RunPeriodically("prune-expired-tokens", pruneInterval, func() error {
+		if _, err := application.RevokedTokenRepo.DeleteExpired(time.Now()); err != nil {
+			return err
+		}
+		if _, err := application.RefreshTokenRepo.DeleteExpired(time.Now()); err != nil {
+			return err
+		}
+		if _, err := application.SessionRepo.DeleteExpired(time.Now()); err != nil {
+			return err
+		}
+		if _, err := application.WebAuthnChallengeRepo.DeleteExpired(time.Now()); err != nil {
+			return err
+		}
+		if _, err := application.PasswordResetTokenRepo.DeleteExpired(time.Now()); err != nil {
+			return err
+		}
+		if _, err := application.IPLoginFailureRepo.DeleteStale(time.Now().Add(-application.LockoutPolicy.ResetAfter)); err != nil {
+			return err
+		}
+		_, err := rateLimitRepo.DeleteStale(time.Now().Add(-rateLimits.RefillTime()))
+		return err
+	})
+	defer stopPruner()
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userService)
+	authHandler := handlers.NewAuthHandler(application.AuthService)
+	userHandler := handlers.NewUserHandler(application.UserService)
+	sessionHandler := handlers.NewSessionHandler(application.SessionService)
+	jwksHandler := handlers.NewJWKSHandler(application.JWTManager)
+	mfaHandler := handlers.NewMFAHandler(application.MFAService)
+	webAuthnHandler := handlers.NewWebAuthnHandler(application.WebAuthnService, application.AuthService)
+	verifyHandler := handlers.NewEmailVerificationHandler(application.EmailVerificationService)
+	resetHandler := handlers.NewPasswordResetHandler(application.PasswordResetService)
+	roleHandler := handlers.NewRoleHandler(application.RoleService)
+	permHandler := handlers.NewPermissionHandler(application.PermissionService)
+	rbacHandler := handlers.NewRBACHandler(application.RBACService)
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
-	permMiddleware := middleware.NewPermissionMiddleware(permissionService)
+	authMiddleware := middleware.NewAuthMiddleware(application.JWTManager, application.RevokedTokenRepo, application.SessionRepo)
+	permMiddleware := middleware.NewPermissionMiddleware(application.PermissionService)
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(rateLimitRepo)
 
 	// Setup routes
 	router := routes.NewRouter(
 		authHandler,
 		userHandler,
+		sessionHandler,
+		jwksHandler,
+		mfaHandler,
+		webAuthnHandler,
+		verifyHandler,
+		resetHandler,
+		roleHandler,
+		permHandler,
+		rbacHandler,
 		authMiddleware,
 		permMiddleware,
+		rateLimitMiddleware,
+		rateLimits,
+		app.SplitList(cfg.Server.TrustedProxies),
 	)
+	handler, err := router.SetupRoutes()
+	if err != nil {
+		log.Fatal("Failed to set up routes:", err)
+	}
+
+	shutdownTimeout, err := time.ParseDuration(cfg.Server.ShutdownTimeout)
+	if err != nil {
+		log.Fatal("Invalid shutdown timeout:", err)
+	}
 
 	server := &http.Server{
 		Addr:    ":" + cfg.Server.Port,
-		Handler: router.SetupRoutes(),
+		Handler: handler,
 	}
 
-	log.Printf("Server starting on port %s", cfg.Server.Port)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatal("Failed to start server:", err)
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Server.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	// Finish the requests in flight, then the mail they queued.
+	log.Println("Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Failed to finish requests: %v", err)
+	}
+	if err := application.Shutdown(ctx); err != nil {
+		log.Printf("Queued mail dropped at shutdown: %v", err)
+	}
+}
+
+func newRateLimits(cfg *config.RateLimitConfig) (middleware.RateLimits, error) {
+	var limits middleware.RateLimits
+	for _, limit := range []struct {
+		target *middleware.RateLimit
+		spec   string
+	}{
+		{&limits.Login, cfg.Login},
+		{&limits.LoginEmail, cfg.LoginEmail},
+		{&limits.Register, cfg.Register},
+		{&limits.Refresh, cfg.Refresh},
+		{&limits.MFA, cfg.MFA},
+		{&limits.Email, cfg.Email},
+		{&limits.EmailAddress, cfg.EmailAddress},
+		{&limits.User, cfg.User},
+	} {
+		parsed, err := middleware.ParseRateLimit(limit.spec)
+		if err != nil {
+			return middleware.RateLimits{}, err
+		}
+		*limit.target = parsed
 	}
+	return limits, nil
 }